@@ -0,0 +1,480 @@
+/*
+Package analysis provides an analyzer that checks the flags and args structs
+of gah commands statically, reporting problems that would otherwise only be
+found by package validate at runtime, or not at all.
+
+The analyzer inspects the Function of each gah.Cmd composite literal, and
+reports:
+
+  - tag keys that look like misspellings of gah's, such as minval instead
+    of minVal
+  - tag values that can't be parsed, such as takesVal:"maybe"
+  - short and long flag names that conflict with each other
+  - fields of types that gah can't unmarshal
+
+Fields of unsupported types are not reported for commands that declare custom
+unmarshallers, since they may provide them.
+
+Commands that aren't composite literals, such as those returned by gah.NewCmd
+or built from structs by gah.FromStruct, are not checked, since their fields
+are set by options or reflection that the analyzer can't follow. Running
+package validate in their tests checks them instead.
+
+It can be run with go vet using the gahvet command:
+
+	go install mtoohey.com/gah/analysis/cmd/gahvet
+	go vet -vettool=$(which gahvet) ./...
+*/
+package analysis
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"mtoohey.com/gah/internal/fields"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "gah",
+	Doc:      "check the flags and args structs of gah commands",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// knownTags are the tag keys used by gah on flags and args fields.
+var knownTags = []string{
+	"aliases", "choices", "default", "deprecated", "elementWise",
+	"fromFile", "greedy", "group", "help", "hidden", "hint", "invert", "lazy",
+	"long", "max",
+	"maxSize", "maxVal", "min", "minVal", "name", "optionalVal", "path",
+	"placeholder", "prefix", "prompt", "required", "secret", "short", "stdin",
+	"subcommandArgs", "takesVal", "trim",
+}
+
+// valueTypes are the types with builtin value unmarshallers, and
+// valuelessTypes those with builtin valueless unmarshallers.
+var valueTypes = map[string]bool{
+	"bool": true, "string": true, "float32": true, "float64": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"time.Duration": true, "net.IP": true, "net.IPNet": true,
+	"*regexp.Regexp": true, "[]byte": true, "[]uint8": true,
+}
+
+var valuelessTypes = map[string]bool{
+	"bool": true,
+	"int":  true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	inspect.Preorder([]ast.Node{(*ast.CompositeLit)(nil)}, func(n ast.Node) {
+		lit := n.(*ast.CompositeLit)
+		if !isCmd(pass.TypesInfo.TypeOf(lit)) {
+			return
+		}
+
+		c := &checker{pass: pass}
+		var function ast.Expr
+		for _, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+
+			key, ok := kv.Key.(*ast.Ident)
+			if !ok {
+				continue
+			}
+
+			switch key.Name {
+			case "Function":
+				function = kv.Value
+			case "CustomValueUnmarshallers", "CustomValuelessUnmarshallers":
+				c.custom = true
+			case "ShortFlags":
+				c.shortFlagPolicy = true
+			}
+		}
+
+		if function == nil {
+			return
+		}
+		c.pos = function.Pos()
+
+		sig, ok := pass.TypesInfo.TypeOf(function).Underlying().(*types.Signature)
+		if !ok || sig.Params().Len() != 2 {
+			pass.Reportf(function.Pos(), "gah command function should take flags and args structs")
+			return
+		}
+
+		// the types of generic functions, such as those passed to NewCmd,
+		// aren't known
+		if isTypeParam(sig.Params().At(0).Type()) || isTypeParam(sig.Params().At(1).Type()) {
+			return
+		}
+
+		flags, flagsOk := sig.Params().At(0).Type().Underlying().(*types.Struct)
+		args, argsOk := sig.Params().At(1).Type().Underlying().(*types.Struct)
+		if !flagsOk || !argsOk {
+			pass.Reportf(function.Pos(), "gah command function should take flags and args structs")
+			return
+		}
+
+		c.checkFlags(flags, "", "")
+		c.checkArgs(args)
+	})
+
+	return nil, nil
+}
+
+func isTypeParam(t types.Type) bool {
+	_, ok := t.(*types.TypeParam)
+	return ok
+}
+
+// isCmd reports whether t is gah.Cmd.
+func isCmd(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "mtoohey.com/gah" && obj.Name() == "Cmd"
+}
+
+// checker checks the function of a single command.
+type checker struct {
+	pass *analysis.Pass
+	// pos is the position of the command's function, where problems with
+	// fields declared outside of the package being analyzed are reported
+	pos token.Pos
+	// custom is whether the command declares custom unmarshallers
+	custom bool
+	// shortFlagPolicy is whether the command sets its ShortFlags policy, in
+	// which case the short flags of its fields aren't known
+	shortFlagPolicy bool
+	shorts          map[string]string
+	longs           map[string]string
+}
+
+func (c *checker) reportf(field *types.Var, format string, args ...interface{}) {
+	pos := field.Pos()
+	if c.pass.Pkg != field.Pkg() {
+		pos = c.pos
+	}
+
+	c.pass.Reportf(pos, format, args...)
+}
+
+// checkFlags checks the fields of flags, whose long names are prefixed with
+// prefix. Flags in groups are named by their path from the flags struct, which
+// is prefixed with namePrefix.
+func (c *checker) checkFlags(flags *types.Struct, prefix string, namePrefix string) {
+	if c.shorts == nil {
+		c.shorts = map[string]string{}
+		c.longs = map[string]string{}
+	}
+
+	for i := 0; i < flags.NumFields(); i++ {
+		field := flags.Field(i)
+		tag := reflect.StructTag(flags.Tag(i))
+		if !field.Exported() {
+			continue
+		}
+
+		st, isStruct := field.Type().Underlying().(*types.Struct)
+		if field.Anonymous() && isStruct {
+			c.checkFlags(st, prefix, namePrefix)
+			continue
+		}
+
+		c.checkKeys(field, tag)
+
+		if groupPrefix, found := tag.Lookup("prefix"); found && isStruct {
+			c.checkFlags(st, prefix+groupPrefix, namePrefix+field.Name()+".")
+			continue
+		}
+
+		c.checkValues(field, tag)
+		c.checkNames(field, tag, prefix, namePrefix)
+
+		takesVal := typeString(field.Type()) != "bool"
+		if s, found := tag.Lookup("takesVal"); found {
+			if b, err := strconv.ParseBool(s); err == nil {
+				takesVal = b
+			}
+		}
+
+		if c.custom {
+			continue
+		}
+
+		if takesVal && !hasValueUnmarshaller(field.Type()) {
+			c.reportf(field, "gah has no value unmarshaller for flag %s of type %s",
+				field.Name(), typeString(field.Type()))
+		} else if !takesVal && !valuelessTypes[typeString(field.Type())] {
+			c.reportf(field, "gah has no valueless unmarshaller for flag %s of type %s",
+				field.Name(), typeString(field.Type()))
+		}
+	}
+}
+
+func (c *checker) checkArgs(args *types.Struct) {
+	for i := 0; i < args.NumFields(); i++ {
+		field := args.Field(i)
+		tag := reflect.StructTag(args.Tag(i))
+		if !field.Exported() {
+			continue
+		}
+
+		c.checkKeys(field, tag)
+		c.checkValues(field, tag)
+
+		if _, found := tag.Lookup("subcommandArgs"); found {
+			if typeString(field.Type()) != "[]string" {
+				c.reportf(field, "subcommandArgs field %s should be of type []string", field.Name())
+			}
+			continue
+		}
+
+		if !c.custom && !hasValueUnmarshaller(field.Type()) {
+			c.reportf(field, "gah has no value unmarshaller for argument %s of type %s",
+				field.Name(), typeString(field.Type()))
+		}
+	}
+}
+
+// checkKeys reports keys of tag that look like misspellings of those used by
+// gah. Other keys are left alone, since flags and args structs may also be
+// used with other packages, such as encoding/json.
+func (c *checker) checkKeys(field *types.Var, tag reflect.StructTag) {
+	for _, pair := range fields.TagPairs(tag) {
+		if suggestion, found := misspelledTag(pair[0]); found {
+			c.reportf(field, "unknown gah tag %s on %s, did you mean %s?", pair[0], field.Name(), suggestion)
+		}
+	}
+}
+
+// misspelledTag returns the tag key used by gah that key is a likely
+// misspelling of, if there is one. Keys are likely misspellings if they
+// differ only in case, or by a single edit for keys of four or more
+// characters.
+func misspelledTag(key string) (string, bool) {
+	for _, k := range knownTags {
+		if key == k {
+			return "", false
+		}
+	}
+
+	for _, k := range knownTags {
+		if strings.EqualFold(key, k) ||
+			(utf8.RuneCountInString(key) >= 4 && withinOneEdit(strings.ToLower(key), strings.ToLower(k))) {
+			return k, true
+		}
+	}
+
+	return "", false
+}
+
+// withinOneEdit reports whether a and b differ by at most one inserted,
+// deleted or substituted rune.
+func withinOneEdit(a, b string) bool {
+	ar, br := []rune(a), []rune(b)
+	if len(ar) > len(br) {
+		ar, br = br, ar
+	}
+	if len(br)-len(ar) > 1 {
+		return false
+	}
+
+	i := 0
+	for i < len(ar) && ar[i] == br[i] {
+		i++
+	}
+
+	if len(ar) == len(br) {
+		return string(ar[i+min(1, len(ar)-i):]) == string(br[i+min(1, len(br)-i):])
+	}
+	return string(ar[i:]) == string(br[i+1:])
+}
+
+// checkValues reports values of tag that can't be parsed.
+func (c *checker) checkValues(field *types.Var, tag reflect.StructTag) {
+	for _, key := range []string{"takesVal", "elementWise"} {
+		if s, found := tag.Lookup(key); found {
+			if _, err := strconv.ParseBool(s); err != nil {
+				c.reportf(field, "invalid %s value %q on %s, should be a bool", key, s, field.Name())
+			}
+		}
+	}
+
+	for _, key := range []string{"min", "max", "maxSize"} {
+		if s, found := tag.Lookup(key); found {
+			if _, err := strconv.Atoi(s); err != nil {
+				c.reportf(field, "invalid %s value %q on %s, should be an integer", key, s, field.Name())
+			}
+		}
+	}
+
+	if s, found := tag.Lookup("fromFile"); found && s != "" && s != "always" {
+		c.reportf(field, `invalid fromFile value %q on %s, should be "" or "always"`, s, field.Name())
+	}
+
+	if s, found := tag.Lookup("trim"); found && s != "newline" && s != "space" {
+		c.reportf(field, `invalid trim value %q on %s, should be "newline" or "space"`, s, field.Name())
+	}
+
+	for _, key := range []string{"default", "minVal", "maxVal", "optionalVal"} {
+		if s, found := tag.Lookup(key); found {
+			if err := parseValue(field.Type(), s); err != nil {
+				c.reportf(field, "invalid %s value %q on %s: %v", key, s, field.Name(), err)
+			}
+		}
+	}
+}
+
+// checkNames reports short and long names of field that are malformed or
+// conflict with those of earlier flags. Flags in groups, which have a
+// namePrefix, only have explicit short names.
+func (c *checker) checkNames(field *types.Var, tag reflect.StructTag, prefix string, namePrefix string) {
+	name := namePrefix + field.Name()
+
+	short, found := tag.Lookup("short")
+	var shorts []string
+	if found && short != "-" {
+		shorts = strings.Split(short, ",")
+	} else if !found && namePrefix == "" {
+		shorts = []string{string(unicode.ToLower([]rune(field.Name())[0]))}
+	}
+
+	for _, s := range shorts {
+		if utf8.RuneCountInString(s) != 1 {
+			c.reportf(field, "invalid short flag %q on %s, should be a single character", s, name)
+			continue
+		}
+
+		if c.shortFlagPolicy {
+			continue
+		}
+
+		if other, found := c.shorts[s]; found {
+			c.reportf(field, "short flag -%s of %s conflicts with %s", s, name, other)
+		} else {
+			c.shorts[s] = name
+		}
+	}
+
+	long, found := tag.Lookup("long")
+	if !found {
+		long = fields.PascalToKebab(field.Name())
+	}
+	longs := strings.Split(long, ",")
+	if aliases, found := tag.Lookup("aliases"); found {
+		longs = append(longs, strings.Split(aliases, ",")...)
+	}
+
+	for _, l := range longs {
+		if l == "" {
+			c.reportf(field, "empty long flag on %s", name)
+			continue
+		}
+
+		l = prefix + l
+		if other, found := c.longs[l]; found {
+			c.reportf(field, "long flag --%s of %s conflicts with %s", l, name, other)
+		} else {
+			c.longs[l] = name
+		}
+	}
+}
+
+func typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string { return p.Name() })
+}
+
+// hasValueUnmarshaller reports whether gah has a builtin value unmarshaller
+// for t, or for its elements if it is a slice or array.
+func hasValueUnmarshaller(t types.Type) bool {
+	if valueTypes[typeString(t)] {
+		return true
+	}
+
+	switch u := t.(type) {
+	case *types.Slice:
+		return valueTypes[typeString(u.Elem())]
+	case *types.Array:
+		return valueTypes[typeString(u.Elem())]
+	}
+
+	return false
+}
+
+// parseValue returns an error if s can't be unmarshalled as a value of type
+// t, for the types whose values can be parsed statically. Slices and arrays
+// are parsed as comma separated elements.
+func parseValue(t types.Type, s string) error {
+	switch u := t.(type) {
+	case *types.Slice:
+		if !valueTypes[typeString(t)] {
+			return parseElements(u.Elem(), s)
+		}
+	case *types.Array:
+		return parseElements(u.Elem(), s)
+	}
+
+	var err error
+	switch typeString(t) {
+	case "bool":
+		_, err = strconv.ParseBool(s)
+	case "int", "int8", "int16", "int32", "int64":
+		_, err = strconv.ParseInt(s, 10, bitSize(t))
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		_, err = strconv.ParseUint(s, 10, bitSize(t))
+	case "float32", "float64":
+		_, err = strconv.ParseFloat(s, bitSize(t))
+	case "time.Duration":
+		_, err = time.ParseDuration(s)
+	}
+
+	if numErr, ok := err.(*strconv.NumError); ok {
+		return numErr.Err
+	}
+	return err
+}
+
+func parseElements(t types.Type, s string) error {
+	for _, e := range strings.Split(s, ",") {
+		if err := parseValue(t, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func bitSize(t types.Type) int {
+	switch typeString(t) {
+	case "int8", "uint8":
+		return 8
+	case "int16", "uint16":
+		return 16
+	case "int32", "uint32", "float32":
+		return 32
+	default:
+		return 64
+	}
+}
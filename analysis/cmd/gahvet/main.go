@@ -0,0 +1,12 @@
+// Command gahvet checks the flags and args structs of gah commands, and can be
+// run with go vet -vettool.
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+	"mtoohey.com/gah/analysis"
+)
+
+func main() {
+	singlechecker.Main(analysis.Analyzer)
+}
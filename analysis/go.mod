@@ -0,0 +1,16 @@
+module mtoohey.com/gah/analysis
+
+go 1.23.0
+
+require (
+	golang.org/x/tools v0.36.0
+	mtoohey.com/gah v0.0.0
+)
+
+require (
+	golang.org/x/mod v0.27.0 // indirect
+	golang.org/x/sync v0.16.0 // indirect
+)
+
+// the analyzer shares code with gah through mtoohey.com/gah/internal
+replace mtoohey.com/gah => ../
@@ -0,0 +1,92 @@
+package a
+
+import (
+	"net"
+	"reflect"
+	"time"
+
+	"mtoohey.com/gah"
+)
+
+type level int
+
+type options struct {
+	Host string // want `long flag --host of Primary.Host conflicts with Host`
+	Port int
+}
+
+var valid = gah.Cmd{
+	Function: func(f struct {
+		Verbose  bool          `short:"V" help:"Be verbose."`
+		Count    int           `minVal:"1" maxVal:"10" default:"3" yaml:"count" toml:"count"`
+		Timeout  time.Duration `default:"5s"`
+		Addr     net.IP
+		Sizes    []uint8 `default:"1,2"`
+		Primary  options `prefix:"primary-"`
+		Replica  options `prefix:"replica-"`
+		Progress bool    `short:"P" takesVal:"false" invert:""`
+	}, a struct {
+		Files []string `min:"1" help:"Files to read."`
+	}) {
+	},
+}
+
+var invalid = gah.Cmd{
+	Subcommands: []gah.Cmd{
+		{
+			Function: func(f struct {
+				Count   int           `minval:"1"`              // want `unknown gah tag minval on Count, did you mean minVal\?`
+				Name    string        `json:"name" requird:""`  // want `unknown gah tag requird on Name, did you mean required\?`
+				Retries int           `default:"many"`          // want `invalid default value "many" on Retries: invalid syntax`
+				Small   int8          `maxVal:"300"`            // want `invalid maxVal value "300" on Small: value out of range`
+				Quiet   bool          `takesVal:"maybe"`        // want `invalid takesVal value "maybe" on Quiet, should be a bool`
+				Wait    time.Duration `default:"soon"`          // want `invalid default value "soon" on Wait: time: invalid duration "soon"`
+				Output  string        `short:"o" trim:"all"`    // want `invalid trim value "all" on Output, should be "newline" or "space"`
+				Other   string        `short:"o"`               // want `short flag -o of Other conflicts with Output`
+				Out     string        `short:"-" long:"output"` // want `long flag --output of Out conflicts with Output`
+				Long    string        `short:"ll"`              // want `invalid short flag "ll" on Long, should be a single character`
+				Level   level         // want `gah has no value unmarshaller for flag Level of type a.level`
+				Ratio   float64       `short:"-" takesVal:"false"` // want `gah has no valueless unmarshaller for flag Ratio of type float64`
+			}, a struct {
+				Files []string   `min:"one"` // want `invalid min value "one" on Files, should be an integer`
+				Chans []chan int // want `gah has no value unmarshaller for argument Chans of type \[\]chan int`
+				Rest  []int      `subcommandArgs:""` // want `subcommandArgs field Rest should be of type \[\]string`
+			}) {
+			},
+		},
+		{
+			Function: func(struct{}) {}, // want `gah command function should take flags and args structs`
+		},
+		{
+			Function: func(f struct {
+				Level level
+			}, _ struct{}) {
+			},
+			CustomValueUnmarshallers: map[reflect.Type]interface{}{},
+		},
+	},
+}
+
+var groups = gah.Cmd{
+	Function: func(f struct {
+		Host    string  `short:"H"`
+		Primary options `prefix:""`
+		Backup  options `prefix:"backup-"`
+		Hidden  bool    `short:"-" hidden:"" deprecated:"use --quiet"`
+	}, _ struct{}) {
+	},
+}
+
+func newCmd[F, A any](fn func(F, A)) gah.Cmd {
+	return gah.Cmd{Function: fn}
+}
+
+var versioned = gah.Cmd{
+	Version: "1.0",
+	Function: func(f struct {
+		// the user's flags shadow the builtin -h and -v
+		Verbose bool
+		Host    string
+	}, _ struct{}) {
+	},
+}
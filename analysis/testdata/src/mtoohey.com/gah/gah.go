@@ -0,0 +1,12 @@
+package gah
+
+import "reflect"
+
+type Cmd struct {
+	Name                     string
+	Version                  string
+	Function                 interface{}
+	Subcommands              []Cmd
+	ShortFlags               int
+	CustomValueUnmarshallers map[reflect.Type]interface{}
+}
@@ -4,7 +4,13 @@ validation.
 */
 package gah
 
-import "mtoohey.com/gah/unmarshal"
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"mtoohey.com/gah/unmarshal"
+)
 
 // TODO: first class completion support
 // TODO: godocs!
@@ -15,11 +21,313 @@ type Cmd struct {
 	Author      string
 	Version     string
 	Description string
-	// TODO: restrict the values of this as much as possible with some
-	// modification of `interface{ []Cmd | interface{} }`
-	Function                     interface{}
-	Subcommands                  []Cmd
+	// LongDescription is shown after Description in long help.
+	LongDescription string
+	// Group is the heading this command is listed under in its parent's help.
+	Group string
+	// Hidden and deprecated commands can still be invoked, but are not listed
+	// in their parent's help. Deprecated holds a message that is shown as a
+	// warning when the command is used.
+	Hidden     bool
+	Deprecated string
+	// Function is invoked with the parsed flags and args when this command is
+	// the one selected. When the command has subcommands it is never invoked,
+	// and only declares the flags accepted before the subcommand. NewCmd
+	// can be used to build commands whose Function is checked at compile
+	// time.
+	Function    interface{}
+	Subcommands []Cmd
+	// PreRun and PostRun are run before and after the selected command's
+	// Function, for this command and any of its subcommands. PostRun is not
+	// run if an earlier step fails.
+	PreRun  Hook
+	PostRun Hook
+	// Middleware wraps the run of this command and any of its subcommands,
+	// with the first item being the outermost.
+	Middleware []Middleware
+	// ParseMode controls how flags and positional arguments may be mixed.
+	ParseMode ParseMode
+	// NegativeNumbers allows positional arguments such as -5, -3.2 or -1e3 to
+	// be given without a preceding "--", when the next positional argument is
+	// numeric and none of the command's short flags are digits.
+	NegativeNumbers bool
+	// ResponseFiles enables replacing arguments of the form @path with the
+	// arguments read from the file at path, which are split like a shell
+	// would, may contain comments and may reference other response files.
+	// Values of flags tagged with fromFile are read by the flag instead, and
+	// @@path can be used to give @path verbatim. It is only used from the
+	// root command.
+	ResponseFiles bool
+	// SkipValidation disables checking the command tree with Validate before
+	// it is parsed. It is only used from the root command.
+	SkipValidation bool
+	// ShortFlags controls which of this command's flags are given short names.
+	ShortFlags ShortFlagPolicy
+	// Stderr is where errors, warnings and help are written, defaulting to
+	// os.Stderr. It is only used from the root command.
+	Stderr io.Writer
+	// Stdout is where the spec requested with --gah-spec is written,
+	// defaulting to os.Stdout. It is only used from the root command.
+	Stdout io.Writer
+	// Prompter is used to ask for the values of flags and arguments tagged
+	// with prompt that were not provided, defaulting to a TerminalPrompter on
+	// stdin. Values are prompted for with the tag's message, without echoing
+	// if they are also tagged with secret, and are asked for again if they
+	// fail to unmarshal. It is only used from the root command.
+	Prompter Prompter
+	// HelpTemplate is a text/template used to render help, see WriteHelp.
+	HelpTemplate                 string
+	Examples                     []Example
 	DefaultFlags                 interface{}
 	CustomValueUnmarshallers     unmarshal.CustomValueUnmarshallers
 	CustomValuelessUnmarshallers unmarshal.CustomValuelessUnmarshallers
 }
+
+// ParseMode controls where flag parsing stops. In every mode, flag parsing
+// stops at "--", and the arguments following it are passed verbatim to the
+// args field tagged with subcommandArgs if there is one, and are otherwise
+// treated as positional arguments.
+type ParseMode int
+
+const (
+	// ParseGNU allows flags and positional arguments to be interleaved.
+	ParseGNU ParseMode = iota
+	// ParsePOSIX stops parsing flags at the first positional argument, like
+	// getopt does with POSIXLY_CORRECT set. Arguments following it are
+	// treated as positional arguments, even if there is a field tagged with
+	// subcommandArgs.
+	ParsePOSIX
+	// ParsePassthrough stops parsing flags at the first positional argument,
+	// passing the arguments following it verbatim to the field tagged with
+	// subcommandArgs, for commands that forward them to another process.
+	// Without such a field, it behaves like ParsePOSIX.
+	ParsePassthrough
+)
+
+// ShortFlagPolicy controls how flags are given short names. Regardless of the
+// policy, a flag tagged with short:"-" has no short name.
+type ShortFlagPolicy int
+
+const (
+	// ShortFlagsAuto uses the short tag if present, and otherwise the
+	// lowercased first rune of the field name.
+	ShortFlagsAuto ShortFlagPolicy = iota
+	// ShortFlagsExplicit only gives short names to flags with a short tag.
+	ShortFlagsExplicit
+	// ShortFlagsNone gives no flags short names.
+	ShortFlagsNone
+)
+
+// Runner runs a parsed command line.
+type Runner func(r *ParseResult) error
+
+// Middleware wraps a Runner, typically to do work before and after next.
+type Middleware func(next Runner) Runner
+
+// Hook is run before or after a command, with the parsed flags of the command
+// it was declared on.
+type Hook func(flags interface{}, r *ParseResult) error
+
+// CommandSpec is a machine-readable description of a command and its
+// subcommands.
+type CommandSpec struct {
+	Name        string        `json:"name"`
+	Aliases     []string      `json:"aliases,omitempty"`
+	Author      string        `json:"author,omitempty"`
+	Version     string        `json:"version,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Group       string        `json:"group,omitempty"`
+	Hidden      bool          `json:"hidden,omitempty"`
+	Deprecated  string        `json:"deprecated,omitempty"`
+	Flags       []FlagSpec    `json:"flags"`
+	Args        []ArgSpec     `json:"args"`
+	Subcommands []CommandSpec `json:"subcommands,omitempty"`
+}
+
+// FlagSpec describes a single flag accepted by a command.
+type FlagSpec struct {
+	// Field is the name of the struct field the flag populates.
+	Field string `json:"field"`
+	// Short and Long are the primary names of the flag, and ShortAliases and
+	// Aliases hold any others. Short is empty if the flag has no short names.
+	Short        string   `json:"short"`
+	Long         string   `json:"long"`
+	ShortAliases []string `json:"shortAliases,omitempty"`
+	Aliases      []string `json:"aliases,omitempty"`
+	Type         string   `json:"type"`
+	TakesVal     bool     `json:"takesVal"`
+	MinVal       string   `json:"minVal,omitempty"`
+	MaxVal       string   `json:"maxVal,omitempty"`
+	Default      string   `json:"default,omitempty"`
+	Choices      []string `json:"choices,omitempty"`
+	Required     bool     `json:"required"`
+	Help         string   `json:"help,omitempty"`
+	Hidden       bool     `json:"hidden,omitempty"`
+	Deprecated   string   `json:"deprecated,omitempty"`
+	// OptionalVal reports whether the flag's value may be omitted, in which
+	// case ImplicitVal is used.
+	OptionalVal bool   `json:"optionalVal,omitempty"`
+	ImplicitVal string `json:"implicitVal,omitempty"`
+	// Placeholder is the name of the flag's value shown in help, such as FILE
+	// in --output=FILE. Hint describes the kind of value the flag takes to
+	// completion scripts, such as "file" or "dir".
+	Placeholder string `json:"placeholder,omitempty"`
+	Hint        string `json:"hint,omitempty"`
+	// Group is the heading the flag is listed under in help, see FlagFields.
+	Group string `json:"group,omitempty"`
+}
+
+// ShortNames returns every short name of the flag, primary first, each with
+// its leading dash.
+func (f FlagSpec) ShortNames() []string {
+	var names []string
+	for _, short := range append([]string{f.Short}, f.ShortAliases...) {
+		if short != "" {
+			names = append(names, "-"+short)
+		}
+	}
+
+	return names
+}
+
+// LongNames returns every long name of the flag, primary first, each with its
+// leading dashes.
+func (f FlagSpec) LongNames() []string {
+	var names []string
+	for _, long := range append([]string{f.Long}, f.Aliases...) {
+		names = append(names, "--"+long)
+	}
+
+	return names
+}
+
+// ArgSpec describes a single positional argument accepted by a command. Max
+// is math.MaxInt for arguments without an upper bound.
+type ArgSpec struct {
+	Field    string `json:"field"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Min      int    `json:"min"`
+	Max      int    `json:"max"`
+	Optional bool   `json:"optional"`
+	Multiple bool   `json:"multiple"`
+	// Passthrough reports whether the argument receives the arguments
+	// following the point where flag parsing stopped verbatim.
+	Passthrough bool     `json:"passthrough,omitempty"`
+	MinVal      string   `json:"minVal,omitempty"`
+	MaxVal      string   `json:"maxVal,omitempty"`
+	Choices     []string `json:"choices,omitempty"`
+	Help        string   `json:"help,omitempty"`
+	// Placeholder is what is shown for the argument in usage lines, which
+	// defaults to Name. Hint describes the kind of value the argument takes to
+	// completion scripts, such as "file" or "dir".
+	Placeholder string `json:"placeholder"`
+	Hint        string `json:"hint,omitempty"`
+}
+
+// Describe returns a description of c and all of its subcommands, excluding
+// the builtin help subcommand and flags.
+func (c Cmd) Describe() CommandSpec {
+	spec := CommandSpec{
+		Name:        c.Name,
+		Aliases:     c.Aliases,
+		Author:      c.Author,
+		Version:     c.Version,
+		Description: c.Description,
+		Group:       c.Group,
+		Hidden:      c.Hidden,
+		Deprecated:  c.Deprecated,
+		Flags:       c.FlagSpecs(),
+		Args:        c.ArgSpecs(),
+	}
+
+	for _, subcommand := range c.Subcommands {
+		spec.Subcommands = append(spec.Subcommands, subcommand.Describe())
+	}
+
+	return spec
+}
+
+// WriteSpec writes the JSON encoding of c's description to w.
+func (c Cmd) WriteSpec(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(c.Describe())
+}
+
+// FlagSpecs describes the flags accepted by c, excluding the builtin help and
+// version flags.
+func (c Cmd) FlagSpecs() []FlagSpec {
+	if c.Function == nil {
+		return nil
+	}
+
+	flags := getFlags(reflect.TypeOf(c.Function).In(0), c.ShortFlags)
+	specs := make([]FlagSpec, len(flags))
+	for i, flag := range flags {
+		_, required := flag.field.Tag.Lookup("required")
+		_, hidden := flag.field.Tag.Lookup("hidden")
+		specs[i] = FlagSpec{
+			Field:      flag.field.Name,
+			Long:       flag.Long(),
+			Type:       flag.field.Type.String(),
+			TakesVal:   unmarshal.TakesValue(flag.field),
+			MinVal:     flag.field.Tag.Get("minVal"),
+			MaxVal:     flag.field.Tag.Get("maxVal"),
+			Default:    flag.defaultString(c.DefaultFlags),
+			Required:   required,
+			Help:       flag.field.Tag.Get("help"),
+			Hidden:     hidden,
+			Deprecated: flag.field.Tag.Get("deprecated"),
+		}
+		if unmarshal.TakesValue(flag.field) {
+			specs[i].Placeholder = flag.Placeholder()
+		}
+		specs[i].Hint = flag.field.Tag.Get("hint")
+		specs[i].Group = flag.field.Tag.Get("group")
+		specs[i].Choices, _ = unmarshal.Choices(flag.field.Tag)
+		specs[i].ImplicitVal, specs[i].OptionalVal = unmarshal.OptionalValue(flag.field.Tag)
+		if longs := flag.Longs(); len(longs) > 1 {
+			specs[i].Aliases = longs[1:]
+		}
+		for j, short := range flag.Shorts() {
+			if j == 0 {
+				specs[i].Short = string(short)
+			} else {
+				specs[i].ShortAliases = append(specs[i].ShortAliases, string(short))
+			}
+		}
+	}
+
+	return specs
+}
+
+// ArgSpecs describes the positional arguments accepted by c, in order.
+func (c Cmd) ArgSpecs() []ArgSpec {
+	if c.Function == nil {
+		return nil
+	}
+
+	args := getArgs(reflect.TypeOf(c.Function).In(1))
+	specs := make([]ArgSpec, len(args))
+	for i, arg := range args {
+		specs[i] = ArgSpec{
+			Field:    arg.Field().Name,
+			Name:     argName(arg.Field()),
+			Type:     arg.Field().Type.String(),
+			Min:      arg.Min(),
+			Max:      arg.Max(),
+			Optional: arg.Optional(),
+			Multiple: arg.Multiple(),
+			MinVal:   arg.Field().Tag.Get("minVal"),
+			MaxVal:   arg.Field().Tag.Get("maxVal"),
+			Help:     arg.Field().Tag.Get("help"),
+		}
+		specs[i].Choices, _ = unmarshal.Choices(arg.Field().Tag)
+		_, specs[i].Passthrough = arg.(*passthroughArgInfo)
+		specs[i].Placeholder = argPlaceholder(arg.Field())
+		specs[i].Hint = arg.Field().Tag.Get("hint")
+	}
+
+	return specs
+}
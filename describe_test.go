@@ -0,0 +1,108 @@
+package gah
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribe(t *testing.T) {
+	cmd := Cmd{
+		Name:    "root",
+		Version: "v1.0.0",
+		Subcommands: []Cmd{
+			{
+				Name:    "sub",
+				Aliases: []string{"s"},
+				Function: func(f struct {
+					Level int    `minVal:"1" maxVal:"5"`
+					Color string `choices:"always,auto,never"`
+				}, a struct {
+					Files []string `min:"1"`
+				}) {
+				},
+			},
+		},
+	}
+
+	spec := cmd.Describe()
+	assert.Equal(t, "root", spec.Name)
+	assert.Empty(t, spec.Flags)
+	assert.Len(t, spec.Subcommands, 1)
+
+	sub := spec.Subcommands[0]
+	assert.Equal(t, []string{"s"}, sub.Aliases)
+	assert.Equal(t, []FlagSpec{
+		{Field: "Level", Short: "l", Long: "level", Type: "int", TakesVal: true,
+			MinVal: "1", MaxVal: "5", Placeholder: "LEVEL"},
+		{Field: "Color", Short: "c", Long: "color", Type: "string", TakesVal: true,
+			Choices: []string{"always", "auto", "never"}, Placeholder: "COLOR"},
+	}, sub.Flags)
+	assert.Equal(t, []ArgSpec{
+		{Field: "Files", Name: "FILES", Type: "[]string", Min: 1, Max: math.MaxInt,
+			Multiple: true, Placeholder: "FILES"},
+	}, sub.Args)
+
+	var buf bytes.Buffer
+	assert.NoError(t, cmd.WriteSpec(&buf))
+	var decoded CommandSpec
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, spec, decoded)
+}
+
+func TestSpecFlag(t *testing.T) {
+	res, err := simpleUnversionedCmd.Parse([]string{"", "--gah-spec"}, nil)
+	assert.NoError(t, err)
+	assert.True(t, res.Spec)
+
+	var stdout, stderr bytes.Buffer
+	cmd := simpleUnversionedCmd
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	assert.NoError(t, cmd.Eval([]string{"", "--gah-spec"}, nil))
+	var decoded CommandSpec
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &decoded))
+	assert.Equal(t, cmd.Describe(), decoded)
+	assert.Empty(t, stderr.String())
+}
+
+func TestChoices(t *testing.T) {
+	var color string
+
+	cmd := Cmd{
+		Function: func(f struct {
+			Color string `choices:"always,auto,never"`
+		}, _ struct{}) {
+			color = f.Color
+		},
+	}
+
+	assert.NoError(t, cmd.Eval([]string{"", "--color", "never"}, nil))
+	assert.Equal(t, "never", color)
+	assert.ErrorIs(t, cmd.Eval([]string{"", "--color", "sometimes"}, nil),
+		&ErrUnmarshallingFlagValue{})
+
+	var level int
+	var sizes []int
+
+	cmd = Cmd{
+		Function: func(f struct {
+			Level int `choices:"1,2,3"`
+		}, a struct {
+			Sizes []int `choices:"8,16"`
+		}) {
+			level = f.Level
+			sizes = a.Sizes
+		},
+	}
+
+	assert.NoError(t, cmd.Eval([]string{"", "--level", "02", "16", "8"}, nil))
+	assert.Equal(t, 2, level)
+	assert.Equal(t, []int{16, 8}, sizes)
+	assert.ErrorIs(t, cmd.Eval([]string{"", "--level", "4"}, nil),
+		&ErrUnmarshallingFlagValue{})
+	assert.ErrorIs(t, cmd.Eval([]string{"", "8", "12"}, nil),
+		&ErrUnmarshallingArgument{})
+}
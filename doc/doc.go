@@ -0,0 +1,214 @@
+/*
+Package doc renders reference documentation for gah command trees.
+
+It is intended to be run from a small program invoked by go generate, for
+example:
+
+	//go:generate go run ./gendocs
+
+where gendocs calls GenerateMarkdown with the application's root command.
+*/
+package doc
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mtoohey.com/gah"
+)
+
+// GenerateMarkdown writes one Markdown file for c and each of its
+// subcommands to dir. Files are named after the command's full path joined
+// with dashes, such as "root-sub.md", and link to their parent and children.
+func GenerateMarkdown(c gah.Cmd, dir string) error {
+	return generateMarkdown(c, dir, []string{})
+}
+
+func generateMarkdown(c gah.Cmd, dir string, parentNames []string) error {
+	f, err := os.Create(filepath.Join(dir, fileName(parentNames, c.Name)))
+	if err != nil {
+		return err
+	}
+
+	err = WriteMarkdown(f, c, parentNames)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, subcommand := range c.Subcommands {
+		if subcommand.Hidden {
+			continue
+		}
+
+		err = generateMarkdown(subcommand, dir, childNames(parentNames, c.Name))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteMarkdown writes the Markdown documentation for c alone to w, with
+// links to its parent and children as named by GenerateMarkdown.
+func WriteMarkdown(w io.Writer, c gah.Cmd, parentNames []string) error {
+	names := childNames(parentNames, c.Name)
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", strings.Join(names, " "))
+
+	if len(parentNames) > 0 {
+		fmt.Fprintf(&b, "Parent command: [%s](%s)\n\n", strings.Join(parentNames, " "),
+			fileName(parentNames[:len(parentNames)-1], parentNames[len(parentNames)-1]))
+	}
+
+	if c.Deprecated != "" {
+		fmt.Fprintf(&b, "**Deprecated:** %s\n\n", c.Deprecated)
+	}
+
+	if c.Description != "" {
+		b.WriteString(c.Description + "\n\n")
+	}
+
+	if c.LongDescription != "" {
+		b.WriteString(c.LongDescription + "\n\n")
+	}
+
+	if len(c.Aliases) > 0 {
+		fmt.Fprintf(&b, "Aliases: `%s`\n\n", strings.Join(c.Aliases, "`, `"))
+	}
+
+	b.WriteString("## Usage\n\n```\n" + strings.Join(names, " "))
+	if c.Subcommands != nil {
+		b.WriteString(" [SUBCOMMAND]")
+	}
+	for _, arg := range c.ArgSpecs() {
+		b.WriteString(" " + argUsage(arg))
+	}
+	b.WriteString("\n```\n")
+
+	var flags []gah.FlagSpec
+	for _, flag := range c.FlagSpecs() {
+		if !flag.Hidden {
+			flags = append(flags, flag)
+		}
+	}
+
+	if len(flags) > 0 {
+		b.WriteString("\n## Flags\n\n")
+		b.WriteString("| Short | Long | Type | Default | Required | Description |\n")
+		b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+		for _, flag := range flags {
+			help := flag.Help
+			if flag.Deprecated != "" {
+				help = strings.TrimSpace(help + " **Deprecated:** " + flag.Deprecated)
+			}
+
+			fmt.Fprintf(&b, "| %s | %s | `%s` | %s | %s | %s |\n",
+				codeList(flag.ShortNames()), codeList(flag.LongNames()), flag.Type, code(flag.Default),
+				yesNo(flag.Required), cell(help))
+		}
+	}
+
+	args := c.ArgSpecs()
+	if len(args) > 0 {
+		b.WriteString("\n## Arguments\n\n")
+		b.WriteString("| Name | Type | Count | Description |\n")
+		b.WriteString("| --- | --- | --- | --- |\n")
+		for _, arg := range args {
+			fmt.Fprintf(&b, "| `%s` | `%s` | %s | %s |\n", arg.Name, arg.Type,
+				cardinality(arg), cell(arg.Help))
+		}
+	}
+
+	if len(c.Subcommands) > 0 {
+		b.WriteString("\n## Subcommands\n\n")
+		for _, subcommand := range c.Subcommands {
+			if subcommand.Hidden {
+				continue
+			}
+
+			fmt.Fprintf(&b, "- [%s](%s)", subcommand.Name, fileName(names, subcommand.Name))
+			if subcommand.Description != "" {
+				b.WriteString(": " + subcommand.Description)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func fileName(parentNames []string, name string) string {
+	return strings.Join(childNames(parentNames, name), "-") + ".md"
+}
+
+func childNames(parentNames []string, name string) []string {
+	names := make([]string, len(parentNames), len(parentNames)+1)
+	copy(names, parentNames)
+	return append(names, name)
+}
+
+func argUsage(arg gah.ArgSpec) string {
+	name := arg.Placeholder
+	if arg.Multiple {
+		name = "..." + name
+	}
+
+	if arg.Optional {
+		return "[" + name + "]"
+	}
+
+	return name
+}
+
+func cardinality(arg gah.ArgSpec) string {
+	if arg.Max == math.MaxInt {
+		return fmt.Sprintf("%d or more", arg.Min)
+	} else if arg.Min == arg.Max {
+		return fmt.Sprint(arg.Min)
+	}
+
+	return fmt.Sprintf("%d to %d", arg.Min, arg.Max)
+}
+
+func code(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	return "`" + s + "`"
+}
+
+func codeList(s []string) string {
+	if len(s) == 0 {
+		return ""
+	}
+
+	return "`" + strings.Join(s, "`, `") + "`"
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
+}
+
+// cell escapes s for use inside a table cell.
+func cell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
@@ -0,0 +1,78 @@
+package doc
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mtoohey.com/gah"
+)
+
+type subFlags struct {
+	DryRun bool   `help:"Don't do anything."`
+	Output string `short:"o" required:""`
+	Count  int
+}
+
+var cmd = gah.Cmd{
+	Name:        "root",
+	Description: "Does root things.",
+	Subcommands: []gah.Cmd{
+		{
+			Name:        "sub",
+			Aliases:     []string{"s"},
+			Description: "Does sub things.",
+			Function: func(f subFlags, a struct {
+				Src  []string `min:"1"`
+				Dest string
+			}) {
+			},
+			DefaultFlags: subFlags{Count: 3},
+		},
+	},
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteMarkdown(&buf, cmd.Subcommands[0], []string{"root"}))
+	assert.Equal(t, "# root sub\n"+
+		"\n"+
+		"Parent command: [root](root.md)\n"+
+		"\n"+
+		"Does sub things.\n"+
+		"\n"+
+		"Aliases: `s`\n"+
+		"\n"+
+		"## Usage\n"+
+		"\n"+
+		"```\n"+
+		"root sub ...SRC DEST\n"+
+		"```\n"+
+		"\n"+
+		"## Flags\n"+
+		"\n"+
+		"| Short | Long | Type | Default | Required | Description |\n"+
+		"| --- | --- | --- | --- | --- | --- |\n"+
+		"| `-d` | `--dry-run` | `bool` |  | no | Don't do anything. |\n"+
+		"| `-o` | `--output` | `string` |  | yes |  |\n"+
+		"| `-c` | `--count` | `int` | `3` | no |  |\n"+
+		"\n"+
+		"## Arguments\n"+
+		"\n"+
+		"| Name | Type | Count | Description |\n"+
+		"| --- | --- | --- | --- |\n"+
+		"| `SRC` | `[]string` | 1 or more |  |\n"+
+		"| `DEST` | `string` | 1 |  |\n", buf.String())
+}
+
+func TestGenerateMarkdown(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, GenerateMarkdown(cmd, dir))
+
+	root, err := os.ReadFile(filepath.Join(dir, "root.md"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(root), "- [sub](root-sub.md): Does sub things.\n")
+	assert.FileExists(t, filepath.Join(dir, "root-sub.md"))
+}
@@ -1,6 +1,17 @@
 package gah
 
-import "fmt"
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// UsageError is implemented by errors caused by invalid command line input, as
+// opposed to errors returned while running a command.
+type UsageError interface {
+	error
+	UsageError()
+}
 
 type ErrExpectedSubcommand struct{}
 
@@ -14,6 +25,8 @@ func (e *ErrExpectedSubcommand) Is(target error) bool {
 	return ok
 }
 
+func (e *ErrExpectedSubcommand) UsageError() {}
+
 type ErrInvalidSubcommand struct {
 	subcommand string
 }
@@ -28,6 +41,10 @@ func (e *ErrInvalidSubcommand) Is(target error) bool {
 	return ok
 }
 
+func (e *ErrInvalidSubcommand) UsageError() {}
+
+func (e *ErrInvalidSubcommand) Subcommand() string { return e.subcommand }
+
 type ErrUnexpectedFlag struct {
 	flag string
 }
@@ -42,6 +59,10 @@ func (e *ErrUnexpectedFlag) Is(target error) bool {
 	return ok
 }
 
+func (e *ErrUnexpectedFlag) UsageError() {}
+
+func (e *ErrUnexpectedFlag) Flag() string { return e.flag }
+
 func unexpectedShort(f rune) error {
 	return &ErrUnexpectedFlag{flag: string([]rune{'-', f})}
 }
@@ -64,6 +85,10 @@ func (e *ErrExpectedFlagValue) Is(target error) bool {
 	return ok
 }
 
+func (e *ErrExpectedFlagValue) UsageError() {}
+
+func (e *ErrExpectedFlagValue) Flag() string { return e.flag }
+
 func expectedFlagValueShort(f rune) error {
 	return &ErrExpectedFlagValue{flag: string([]rune{'-', f})}
 }
@@ -87,6 +112,12 @@ func (e *ErrUnexpectedFlagValue) Is(target error) bool {
 	return ok
 }
 
+func (e *ErrUnexpectedFlagValue) UsageError() {}
+
+func (e *ErrUnexpectedFlagValue) Flag() string { return e.flag }
+
+func (e *ErrUnexpectedFlagValue) Value() string { return e.value }
+
 func unexpectedFlagValueShort(f rune, v string) error {
 	return &ErrUnexpectedFlagValue{flag: string([]rune{'-', f}), value: v}
 }
@@ -110,6 +141,12 @@ func (e *ErrUnmarshallingFlagValue) Is(target error) bool {
 	return ok
 }
 
+func (e *ErrUnmarshallingFlagValue) UsageError() {}
+
+func (e *ErrUnmarshallingFlagValue) Flag() string { return e.flag }
+
+func (e *ErrUnmarshallingFlagValue) Unwrap() error { return e.error }
+
 func unmarshallingFlagShort(f rune, e error) error {
 	return &ErrUnmarshallingFlagValue{flag: string([]rune{'-', f}), error: e}
 }
@@ -132,6 +169,10 @@ func (e *ErrUnexpectedArgument) Is(target error) bool {
 	return ok
 }
 
+func (e *ErrUnexpectedArgument) UsageError() {}
+
+func (e *ErrUnexpectedArgument) Argument() string { return e.argument }
+
 type ErrUnmarshallingArgument struct {
 	name  string
 	value string
@@ -149,6 +190,32 @@ func (e *ErrUnmarshallingArgument) Is(target error) bool {
 	return ok
 }
 
+func (e *ErrUnmarshallingArgument) UsageError() {}
+
+func (e *ErrUnmarshallingArgument) Name() string { return e.name }
+
+func (e *ErrUnmarshallingArgument) Value() string { return e.value }
+
+func (e *ErrUnmarshallingArgument) Unwrap() error { return e.error }
+
+type ErrMissingRequiredFlag struct {
+	flag string
+}
+
+func (e *ErrMissingRequiredFlag) Error() string {
+	return fmt.Sprintf("missing required flag %s", e.flag)
+}
+
+func (e *ErrMissingRequiredFlag) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrMissingRequiredFlag)
+	return ok
+}
+
+func (e *ErrMissingRequiredFlag) UsageError() {}
+
+func (e *ErrMissingRequiredFlag) Flag() string { return e.flag }
+
 type ErrExpectedArgumentValue struct {
 	name string
 }
@@ -162,3 +229,363 @@ func (e *ErrExpectedArgumentValue) Is(target error) bool {
 	_, ok := t.(*ErrExpectedArgumentValue)
 	return ok
 }
+
+func (e *ErrExpectedArgumentValue) UsageError() {}
+
+func (e *ErrExpectedArgumentValue) Name() string { return e.name }
+
+type ErrReadingResponseFile struct {
+	path  string
+	error error
+}
+
+func (e *ErrReadingResponseFile) Error() string {
+	return fmt.Sprintf("error reading response file %s: %v", e.path, e.error)
+}
+
+func (e *ErrReadingResponseFile) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrReadingResponseFile)
+	return ok
+}
+
+func (e *ErrReadingResponseFile) UsageError() {}
+
+func (e *ErrReadingResponseFile) Path() string { return e.path }
+
+func (e *ErrReadingResponseFile) Unwrap() error { return e.error }
+
+// ErrFromResponseFile wraps an error caused by an argument that was read from
+// a response file, recording where it was read from.
+type ErrFromResponseFile struct {
+	location Location
+	error    error
+}
+
+func (e *ErrFromResponseFile) Error() string {
+	return fmt.Sprintf("%s: %v", e.location, e.error)
+}
+
+func (e *ErrFromResponseFile) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrFromResponseFile)
+	return ok
+}
+
+func (e *ErrFromResponseFile) UsageError() {}
+
+func (e *ErrFromResponseFile) Location() Location { return e.location }
+
+func (e *ErrFromResponseFile) Unwrap() error { return e.error }
+
+// ErrInvalidCommandDefinition is returned in place of parsing when a command
+// tree is defined incorrectly, such as when its Function has the wrong
+// signature or a tag can't be parsed.
+type ErrInvalidCommandDefinition struct {
+	error error
+}
+
+func (e *ErrInvalidCommandDefinition) Error() string {
+	return fmt.Sprintf("invalid command definition: %v", e.error)
+}
+
+func (e *ErrInvalidCommandDefinition) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrInvalidCommandDefinition)
+	return ok
+}
+
+func (e *ErrInvalidCommandDefinition) Unwrap() error { return e.error }
+
+type ErrFunctionIsNotFunction struct {
+	functionKind reflect.Kind
+}
+
+func (e *ErrFunctionIsNotFunction) Error() string {
+	return fmt.Sprintf("provided function is not a function, found kind %v",
+		e.functionKind)
+}
+
+func (e *ErrFunctionIsNotFunction) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrFunctionIsNotFunction)
+	return ok
+}
+
+type ErrFunctionTakesNonTwoArgs struct {
+	numFunctionArgs int
+}
+
+func (e *ErrFunctionTakesNonTwoArgs) Error() string {
+	return fmt.Sprintf("provided function takes the wrong number of args: %d, should take 2",
+		e.numFunctionArgs)
+}
+
+func (e *ErrFunctionTakesNonTwoArgs) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrFunctionTakesNonTwoArgs)
+	return ok
+}
+
+type ErrFunctionTakesNonStructArg struct {
+	argumentIndex int
+	argumentKind  reflect.Kind
+}
+
+func (e *ErrFunctionTakesNonStructArg) Error() string {
+	return fmt.Sprintf("function argument %d is not of kind struct, found kind: %v",
+		e.argumentIndex, e.argumentKind)
+}
+
+func (e *ErrFunctionTakesNonStructArg) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrFunctionTakesNonStructArg)
+	return ok
+}
+
+type ErrMissingValueUnmarshaller struct {
+	valueType reflect.Type
+}
+
+func (e *ErrMissingValueUnmarshaller) Error() string {
+	return fmt.Sprintf("missing value unmarshaller for type %s, add to CustomValueUnmarshallers",
+		e.valueType)
+}
+
+func (e *ErrMissingValueUnmarshaller) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrMissingValueUnmarshaller)
+	return ok
+}
+
+type ErrMissingValuelessUnmarshaller struct {
+	valueType reflect.Type
+}
+
+func (e *ErrMissingValuelessUnmarshaller) Error() string {
+	return fmt.Sprintf("missing valueless unmarshaller for type %s, add to CustomValuelessUnmarshallers",
+		e.valueType)
+}
+
+func (e *ErrMissingValuelessUnmarshaller) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrMissingValuelessUnmarshaller)
+	return ok
+}
+
+type ErrSubcommandArgsOnIncorrectType struct {
+	argType reflect.Type
+}
+
+func (e *ErrSubcommandArgsOnIncorrectType) Error() string {
+	return fmt.Sprintf("subcommandArgs tag on incorrect type %s, type should be []string",
+		e.argType)
+}
+
+func (e *ErrSubcommandArgsOnIncorrectType) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrSubcommandArgsOnIncorrectType)
+	return ok
+}
+
+type ErrEmptyShortFlag struct {
+	flagName string
+}
+
+func (e *ErrEmptyShortFlag) Error() string {
+	return fmt.Sprintf("empty short flag declared for flag %s", e.flagName)
+}
+
+func (e *ErrEmptyShortFlag) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrEmptyShortFlag)
+	return ok
+}
+
+type ErrEmptyLongFlag struct {
+	flagName string
+}
+
+func (e *ErrEmptyLongFlag) Error() string {
+	return fmt.Sprintf("empty long flag declared for flag %s", e.flagName)
+}
+
+func (e *ErrEmptyLongFlag) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrEmptyLongFlag)
+	return ok
+}
+
+type ErrMultiRuneShortFlag struct {
+	flagName  string
+	shortFlag string
+}
+
+func (e *ErrMultiRuneShortFlag) Error() string {
+	return fmt.Sprintf("multi rune short flag for %s: %s, should be a single rune",
+		e.flagName, e.shortFlag)
+}
+
+func (e *ErrMultiRuneShortFlag) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrMultiRuneShortFlag)
+	return ok
+}
+
+type ErrConflictingShortFlags struct {
+	flagNames []string
+}
+
+func (e *ErrConflictingShortFlags) Error() string {
+	return fmt.Sprintf("conflicting short flags: %s",
+		strings.Join(e.flagNames, ", "))
+}
+
+func (e *ErrConflictingShortFlags) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrConflictingShortFlags)
+	return ok
+}
+
+type ErrConflictingLongFlags struct {
+	flagNames []string
+}
+
+func (e *ErrConflictingLongFlags) Error() string {
+	return fmt.Sprintf("conflicting long flags: %s",
+		strings.Join(e.flagNames, ", "))
+}
+
+func (e *ErrConflictingLongFlags) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrConflictingLongFlags)
+	return ok
+}
+
+type ErrConflictingSubcommands struct {
+	subcommandNames []string
+	aliasOrName     string
+}
+
+func (e *ErrConflictingSubcommands) Error() string {
+	return fmt.Sprintf("conflicting subcommands or aliases %s with: %s",
+		strings.Join(e.subcommandNames, ", "), e.aliasOrName)
+}
+
+func (e *ErrConflictingSubcommands) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrConflictingSubcommands)
+	return ok
+}
+
+type ErrFailingDefault struct {
+	defaultString string
+	flagName      string
+	error         error
+}
+
+func (e *ErrFailingDefault) Error() string {
+	return fmt.Sprintf("failing default value %s for flag %s with error: %v",
+		e.defaultString, e.flagName, e.error)
+}
+
+func (e *ErrFailingDefault) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrFailingDefault)
+	return ok
+}
+
+type ErrMismatchedDynamicDefaultFlags struct {
+	actual   reflect.Type
+	expected reflect.Type
+}
+
+func (e *ErrMismatchedDynamicDefaultFlags) Error() string {
+	return fmt.Sprintf("mismatched dynamic default flags type: %v, expected %v",
+		e.actual, e.expected)
+}
+
+func (e *ErrMismatchedDynamicDefaultFlags) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrMismatchedDynamicDefaultFlags)
+	return ok
+}
+
+// NOTE: this can't validate params in custom unmarshallers, users are
+// responsible for that, the only way to test that here would be to convert
+// unmarshallers to interfaces or structs, which is an overkill solution
+
+type ErrFailingParam struct {
+	paramName   string
+	paramString string
+	flagName    string
+	error       error
+}
+
+func (e *ErrFailingParam) Error() string {
+	return fmt.Sprintf("failing param %s:\"%s\" for flag %s with error: %v",
+		e.paramName, e.paramString, e.flagName, e.error)
+}
+
+func (e *ErrFailingParam) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrFailingParam)
+	return ok
+}
+
+type ErrMultipleVariableArguments struct {
+	argumentNames []string
+}
+
+func (e *ErrMultipleVariableArguments) Error() string {
+	return fmt.Sprintf("multiple variable arguments: %s, deciding which argument belongs where is ambiguous, tag all but the last with greedy or lazy",
+		strings.Join(e.argumentNames, ", "))
+}
+
+func (e *ErrMultipleVariableArguments) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrMultipleVariableArguments)
+	return ok
+}
+
+type ErrArgsAndSubcommands struct{}
+
+func (e *ErrArgsAndSubcommands) Error() string {
+	return "command contains both arguments and subcommands, these are mutually exclusive"
+}
+
+func (e *ErrArgsAndSubcommands) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrArgsAndSubcommands)
+	return ok
+}
+
+// ErrInCommand wraps a problem found by ValidateAll with the path of names to
+// the command it was found in, starting at the root, and the name of the
+// field it concerns, which is empty for problems with the command itself.
+type ErrInCommand struct {
+	path  []string
+	field string
+	error error
+}
+
+func (e *ErrInCommand) Error() string {
+	path := strings.TrimSpace(strings.Join(e.path, " "))
+	if path == "" {
+		return e.error.Error()
+	}
+
+	return fmt.Sprintf("%s: %v", path, e.error)
+}
+
+func (e *ErrInCommand) Is(target error) bool {
+	var t interface{} = target
+	_, ok := t.(*ErrInCommand)
+	return ok
+}
+
+func (e *ErrInCommand) Path() []string { return e.path }
+
+func (e *ErrInCommand) Field() string { return e.field }
+
+func (e *ErrInCommand) Unwrap() error { return e.error }
@@ -1,7 +1,9 @@
 package gah
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"path"
@@ -10,15 +12,88 @@ import (
 	"strings"
 	"unicode"
 
+	"mtoohey.com/gah/internal/fields"
 	"mtoohey.com/gah/unmarshal"
 )
 
+// Exit codes used by SimpleEval, following the conventions of sysexits.h.
+const (
+	ExitFailure = 1
+	ExitUsage   = 64
+)
+
+// SimpleEval evaluates os.Args, reporting any error to stderr and exiting with
+// ExitUsage for usage errors or ExitFailure for any other error.
 func (c Cmd) SimpleEval() {
-	err := c.Eval(os.Args, []string{})
+	stderr := c.stderr()
+	f, ok := stderr.(*os.File)
+	code := c.evalWithExitCode(os.Args, stderr, ok && colorEnabled(f))
+	if code != 0 {
+		os.Exit(code)
+	}
+}
+
+func (c Cmd) evalWithExitCode(inputArgs []string, w io.Writer, color bool) int {
+	res := &ParseResult{}
+	err := c.parseRoot(inputArgs, []string{}, res)
+	if err == nil {
+		err = res.Run()
+		if err == nil {
+			return 0
+		}
+	}
+
+	if color {
+		fmt.Fprintf(w, "\033[31m%v\033[0m\n", err)
+	} else {
+		fmt.Fprintln(w, err)
+	}
+
+	var usageErr UsageError
+	if !errors.As(err, &usageErr) {
+		return ExitFailure
+	}
+
+	if len(res.Chain) != 0 {
+		leaf := res.Leaf()
+		names := strings.Join(childNames(leaf.ParentNames, leaf.Cmd.Name), " ")
+		fmt.Fprintf(w, "\nusage: %s\nsee '%s --help' for more information\n",
+			leaf.Cmd.usage(leaf.ParentNames), names)
+	}
+
+	return ExitUsage
+}
+
+// stderr returns the writer c reports errors, warnings and help to.
+func (c Cmd) stderr() io.Writer {
+	if c.Stderr == nil {
+		return os.Stderr
+	}
+
+	return c.Stderr
+}
+
+func (c Cmd) stdout() io.Writer {
+	if c.Stdout == nil {
+		return os.Stdout
+	}
+
+	return c.Stdout
+}
+
+// colorEnabled reports whether coloured output should be written to f, which
+// is only the case when f is a terminal and NO_COLOR is not set.
+func colorEnabled(f *os.File) bool {
+	if _, found := os.LookupEnv("NO_COLOR"); found {
+		return false
+	}
+
+	info, err := f.Stat()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "\033[31m%v\033[0m\n", err)
-		os.Exit(1)
+		return false
 	}
+
+	return info.Mode()&os.ModeCharDevice != 0
 }
 
 func (c Cmd) EvalMulticall(args []string) {
@@ -32,6 +107,205 @@ func (c Cmd) EvalMulticall(args []string) {
 }
 
 func (c Cmd) Eval(inputArgs []string, parentNames []string) error {
+	res, err := c.Parse(inputArgs, parentNames)
+	if err != nil {
+		return err
+	}
+
+	return res.Run()
+}
+
+// ParseResult is the outcome of parsing a command line without invoking any
+// of the parsed commands' functions.
+type ParseResult struct {
+	// Chain is the resolved command path, starting at the command Parse was
+	// called on and ending at the selected subcommand.
+	Chain []ParsedCmd
+	// Args holds the populated argument struct of the last command in Chain.
+	// It is the zero Value if help or version information was requested.
+	Args reflect.Value
+	// Warnings holds deprecation warnings for the commands that were used,
+	// which Run writes to stderr.
+	Warnings []string
+	// Remaining holds the arguments that were left unconsumed, such as those
+	// following a help or version flag.
+	Remaining []string
+	// Help and Version report whether the builtin help or version flags were
+	// encountered, in which case Run prints the requested information instead
+	// of invoking any functions.
+	Help    bool
+	Version bool
+	// LongHelp reports whether the long form of help was requested.
+	LongHelp bool
+	// Spec reports whether the hidden --gah-spec flag was encountered, in
+	// which case Run writes the JSON description of the command to stdout.
+	Spec bool
+
+	// locations holds the response file location of each argument, and
+	// argOffset is the index in it of the first argument of the command
+	// currently being parsed.
+	locations []*Location
+	argOffset int
+	prompter  Prompter
+}
+
+// ParsedCmd is a single command in a ParseResult's Chain.
+type ParsedCmd struct {
+	Cmd         Cmd
+	ParentNames []string
+	// Flags holds the populated flag struct for this command.
+	Flags reflect.Value
+	// Set reports, by field name, whether each flag was explicitly provided on
+	// the command line.
+	Set map[string]bool
+}
+
+// Leaf returns the last command in the chain.
+func (r *ParseResult) Leaf() ParsedCmd {
+	return r.Chain[len(r.Chain)-1]
+}
+
+// Run invokes the function of the last command in the chain, wrapped in the
+// middleware and pre/post run hooks of every command in the chain, or prints
+// help or version information if it was requested.
+func (r *ParseResult) Run() error {
+	leaf := r.Leaf()
+
+	stderr := r.Chain[0].Cmd.stderr()
+
+	for _, warning := range r.Warnings {
+		fmt.Fprintln(stderr, "warning: "+warning)
+	}
+
+	if r.Help {
+		return leaf.Cmd.WriteHelp(stderr, leaf.ParentNames, r.LongHelp)
+	} else if r.Version {
+		fmt.Fprintln(stderr, leaf.Cmd.Version)
+		return nil
+	} else if r.Spec {
+		return leaf.Cmd.WriteSpec(r.Chain[0].Cmd.stdout())
+	}
+
+	var run Runner = func(r *ParseResult) error {
+		if leaf.Cmd.Function == nil {
+			return nil
+		}
+
+		return call(leaf.Cmd.Function, leaf.Flags, r.Args)
+	}
+
+	for i := len(r.Chain) - 1; i >= 0; i-- {
+		run = r.Chain[i].wrap(run)
+	}
+
+	return run(r)
+}
+
+// wrap surrounds next with p's pre and post run hooks, then with its
+// middleware, so that the first middleware in the list is the outermost.
+func (p ParsedCmd) wrap(next Runner) Runner {
+	run := func(r *ParseResult) error {
+		if p.Cmd.PreRun != nil {
+			err := p.Cmd.PreRun(p.Flags.Interface(), r)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := next(r)
+		if err != nil {
+			return err
+		}
+
+		if p.Cmd.PostRun != nil {
+			return p.Cmd.PostRun(p.Flags.Interface(), r)
+		}
+
+		return nil
+	}
+
+	for i := len(p.Cmd.Middleware) - 1; i >= 0; i-- {
+		run = p.Cmd.Middleware[i](run)
+	}
+
+	return run
+}
+
+// call invokes f with the provided flags and args, returning the error it
+// produced if its last return value is an error.
+func call(f interface{}, flags reflect.Value, args reflect.Value) error {
+	out := reflect.ValueOf(f).Call([]reflect.Value{flags, args})
+	if len(out) == 0 {
+		return nil
+	}
+
+	err, ok := out[len(out)-1].Interface().(error)
+	if ok {
+		return err
+	}
+
+	return nil
+}
+
+// Parse parses inputArgs against c and its subcommands, without invoking any
+// of their functions. The returned result can be inspected, then run with
+// ParseResult.Run.
+func (c Cmd) Parse(inputArgs []string, parentNames []string) (*ParseResult, error) {
+	res := &ParseResult{}
+	err := c.parseRoot(inputArgs, parentNames, res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// parseRoot validates c, expands response files in inputArgs if they are
+// enabled, parses the result, then prompts for any missing flags.
+func (c Cmd) parseRoot(inputArgs []string, parentNames []string, res *ParseResult) error {
+	if err := c.validateTree(); err != nil {
+		return err
+	}
+
+	if c.ResponseFiles {
+		var err error
+		inputArgs, res.locations, err = c.expandResponseFiles(inputArgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	res.prompter = c.prompter()
+
+	err := c.parse(inputArgs, parentNames, res)
+	if err != nil || res.Help || res.Version || res.Spec {
+		return err
+	}
+
+	return res.promptFlags()
+}
+
+// locate wraps err with the location of the argument at index i of the input
+// currently being parsed, if it was read from a response file.
+func (r *ParseResult) locate(i int, err error) error {
+	i += r.argOffset
+	if i >= len(r.locations) || r.locations[i] == nil {
+		return err
+	}
+
+	return &ErrFromResponseFile{location: *r.locations[i], error: err}
+}
+
+func (c Cmd) parse(inputArgs []string, parentNames []string, res *ParseResult) (err error) {
+	// errIndex is the index of the argument responsible for any error that
+	// is returned, so that it can be located if it came from a response file
+	errIndex := -1
+	defer func() {
+		if err != nil && errIndex != -1 {
+			err = res.locate(errIndex, err)
+		}
+	}()
+
 	var flagsType reflect.Type
 	var argsType reflect.Type
 	if c.Function == nil {
@@ -43,6 +317,27 @@ func (c Cmd) Eval(inputArgs []string, parentNames []string) error {
 	}
 	flags := reflect.New(flagsType)
 	var positionalArgs []string
+	var positionalIndices []int
+	var passthroughArgs []string
+
+	addPositional := func(start int, args ...string) {
+		positionalArgs = append(positionalArgs, args...)
+		for i := range args {
+			positionalIndices = append(positionalIndices, start+i)
+		}
+	}
+
+	// stop handles the arguments following the point where flag parsing
+	// stops, which are passed through verbatim if the args struct has a
+	// field to receive them
+	hasPassthrough := hasPassthroughArg(argsType)
+	stop := func(start int) {
+		if hasPassthrough {
+			passthroughArgs = inputArgs[start:]
+		} else {
+			addPositional(start, inputArgs[start:]...)
+		}
+	}
 
 	var enrichedSubcommands []Cmd
 	if c.Subcommands != nil {
@@ -73,34 +368,54 @@ func (c Cmd) Eval(inputArgs []string, parentNames []string) error {
 				Name: "help",
 				Function: func(_ struct{}, a struct {
 					SubcommandName []string `min:"0" max:"1"`
-				}) {
+				}) error {
+					stderr := res.Chain[0].Cmd.stderr()
+
 					if len(a.SubcommandName) > 0 {
 						for _, subcommand := range c.Subcommands {
 							if subcommand.Name == a.SubcommandName[0] {
-								subcommand.PrintHelp(append(parentNames, c.Name))
-								return
+								return subcommand.WriteHelp(stderr, append(parentNames, c.Name), true)
 							}
 
 							for _, alias := range subcommand.Aliases {
 								if alias == a.SubcommandName[0] {
-									subcommand.PrintHelp(append(parentNames, c.Name))
-									return
+									return subcommand.WriteHelp(stderr, append(parentNames, c.Name), true)
 								}
 							}
 						}
 					}
 
-					c.PrintHelp(parentNames)
+					return c.WriteHelp(stderr, parentNames, true)
 				},
 			}
 		}
 	}
 
-	allFlags := getFlags(flagsType)
+	allFlags := getFlags(flagsType, c.ShortFlags)
 	validShort, validLong := getFlagMaps(allFlags)
 
+	// negativeNumber reports whether arg should be treated as a negative
+	// number rather than as short flags
+	negativeNumber := func(string) bool { return false }
+	if c.NegativeNumbers && c.Subcommands == nil && !hasDigitShort(validShort) {
+		argInfo := getArgs(argsType)
+		negativeNumber = func(arg string) bool {
+			return isNumber(arg) && acceptsNumber(argInfo, len(positionalArgs))
+		}
+	}
+
+	res.Chain = append(res.Chain, ParsedCmd{
+		Cmd:         c,
+		ParentNames: parentNames,
+		Flags:       reflect.Indirect(flags),
+	})
+	// the flags are recorded as set and defaulted once parsing of this
+	// command's arguments is complete, regardless of how it completes
+	defer res.finishFlags(len(res.Chain)-1, c, allFlags)
+
 	for i := 1; i < len(inputArgs); i++ {
 		arg := inputArgs[i]
+		errIndex = i
 
 		if strings.HasPrefix(arg, "--") && len(arg) > 2 {
 			eqIndex := strings.IndexRune(arg, '=')
@@ -113,18 +428,21 @@ func (c Cmd) Eval(inputArgs []string, parentNames []string) error {
 
 			flag, ok := validLong[flagName]
 			if !ok {
-				return trySalvageBuiltinLong(c, flagName, parentNames)
+				return trySalvageBuiltinLong(c, flagName, res, inputArgs[i+1:])
 			}
+			res.warnIfDeprecated(flag, "--"+flagName)
 
 			if unmarshal.TakesValue(flag.field) {
 				var flagValue string
 				if eqIndex == -1 {
-					if i == len(inputArgs)-1 {
+					if implicit, ok := unmarshal.OptionalValue(flag.field.Tag); ok {
+						flagValue = implicit
+					} else if i == len(inputArgs)-1 {
 						return expectedFlagValueLong(flagName)
+					} else {
+						i++
+						flagValue = inputArgs[i]
 					}
-
-					i++
-					flagValue = inputArgs[i]
 				} else {
 					flagValue = arg[eqIndex+1:]
 				}
@@ -154,9 +472,9 @@ func (c Cmd) Eval(inputArgs []string, parentNames []string) error {
 				flags.Elem().FieldByIndex((*flag).field.Index).Set(res)
 				flag.set = true
 			}
-		} else if strings.HasPrefix(arg, "-") && len(arg) > 1 {
+		} else if strings.HasPrefix(arg, "-") && len(arg) > 1 && !negativeNumber(arg) {
 			if arg == "--" {
-				positionalArgs = append(positionalArgs, inputArgs[i+1:]...)
+				stop(i + 1)
 				break
 			}
 
@@ -173,19 +491,22 @@ func (c Cmd) Eval(inputArgs []string, parentNames []string) error {
 
 				flag, ok := validShort[flagRune]
 				if !ok {
-					return trySalvageBuiltinShort(c, flagRune, parentNames)
+					return trySalvageBuiltinShort(c, flagRune, res, inputArgs[i+1:])
 				}
+				res.warnIfDeprecated(flag, string([]rune{'-', flagRune}))
 
 				if unmarshal.TakesValue(flag.field) {
 					var flagValue string
 					if j == len(flagRunes)-1 {
 						if eqIndex == -1 {
-							if i == len(inputArgs)-1 {
+							if implicit, ok := unmarshal.OptionalValue(flag.field.Tag); ok {
+								flagValue = implicit
+							} else if i == len(inputArgs)-1 {
 								return expectedFlagValueShort(flagRune)
+							} else {
+								i++
+								flagValue = inputArgs[i]
 							}
-
-							i++
-							flagValue = inputArgs[i]
 						} else {
 							flagValue = arg[eqIndex+1:]
 						}
@@ -225,25 +546,32 @@ func (c Cmd) Eval(inputArgs []string, parentNames []string) error {
 			}
 		} else {
 			if c.Subcommands == nil {
-				positionalArgs = append(positionalArgs, arg)
+				addPositional(i, arg)
+
+				if c.ParseMode == ParsePOSIX {
+					addPositional(i+1, inputArgs[i+1:]...)
+					break
+				} else if c.ParseMode == ParsePassthrough {
+					stop(i + 1)
+					break
+				}
 			} else {
 				for _, subcommand := range enrichedSubcommands {
-					if arg == subcommand.Name {
-						if c.Function != nil {
-							reflect.ValueOf(c.Function).Call([]reflect.Value{reflect.Indirect(flags),
-								reflect.Indirect(reflect.New(reflect.TypeOf(c.Function).In(1)))})
-						}
-						return subcommand.Eval(inputArgs[i:], append(parentNames, c.Name))
+					matched := arg == subcommand.Name
+					for _, alias := range subcommand.Aliases {
+						matched = matched || arg == alias
 					}
 
-					for _, alias := range subcommand.Aliases {
-						if arg == alias {
-							if c.Function != nil {
-								reflect.ValueOf(c.Function).Call([]reflect.Value{reflect.Indirect(flags),
-									reflect.Indirect(reflect.New(reflect.TypeOf(c.Function).In(1)))})
-							}
-							return subcommand.Eval(inputArgs[i:], append(parentNames, c.Name))
+					if matched {
+						if subcommand.Deprecated != "" {
+							res.Warnings = append(res.Warnings, fmt.Sprintf(
+								"subcommand %s is deprecated: %s", arg, subcommand.Deprecated))
 						}
+
+						// the subcommand locates its own errors
+						errIndex = -1
+						res.argOffset += i
+						return subcommand.parse(inputArgs[i:], childNames(parentNames, c.Name), res)
 					}
 				}
 
@@ -252,6 +580,8 @@ func (c Cmd) Eval(inputArgs []string, parentNames []string) error {
 		}
 	}
 
+	errIndex = -1
+
 	if c.Subcommands != nil {
 		return &ErrExpectedSubcommand{}
 	}
@@ -262,85 +592,224 @@ func (c Cmd) Eval(inputArgs []string, parentNames []string) error {
 	minArgs := 0
 	maxArgs := 0
 	for _, arg := range argInfo {
+		if _, ok := arg.(*passthroughArgInfo); ok {
+			continue
+		}
+
 		minArgs += arg.Min()
-		maxArgs += arg.Max()
+		maxArgs = addMax(maxArgs, arg.Max())
 	}
 
 	if len(positionalArgs) < minArgs {
-		for _, arg := range argInfo {
-			minArgs -= arg.Min()
-			if minArgs < 0 {
-				return &ErrExpectedArgumentValue{name: strings.ToUpper(arg.Field().Name)}
+		// the values are assigned in order, so the missing ones belong to the
+		// last arguments, and any that are prompted for can be appended
+		remaining := len(positionalArgs)
+		for _, info := range argInfo {
+			if _, ok := info.(*passthroughArgInfo); ok {
+				continue
+			}
+
+			for remaining -= info.Min(); remaining < 0; remaining++ {
+				message, found := info.Field().Tag.Lookup("prompt")
+				if !found || !res.prompter.Interactive() {
+					return &ErrExpectedArgumentValue{name: argName(info.Field())}
+				}
+
+				value, _, err := promptValue(res.prompter, res.Chain[0].Cmd.stderr(), message,
+					info.Field().Tag, info.Unmarshaller(c.CustomValueUnmarshallers))
+				if err != nil {
+					return err
+				}
+
+				addPositional(-1, value)
 			}
 		}
 	} else if len(positionalArgs) > maxArgs {
+		errIndex = positionalIndices[len(positionalIndices)-1]
 		return &ErrUnexpectedArgument{
 			argument: positionalArgs[len(positionalArgs)-1]}
 	}
 
-	additionalVariableArgs := len(positionalArgs) - minArgs
-
-	i := 0
+	matched := argInfo[:0:0]
 	for _, info := range argInfo {
-		var numToTake int
-		if info.Min() != info.Max() {
-			numToTake = info.Min() + additionalVariableArgs
+		if _, ok := info.(*passthroughArgInfo); ok {
+			info.Update(args, reflect.ValueOf(append([]string{}, passthroughArgs...)))
 		} else {
-			numToTake = info.Min()
+			matched = append(matched, info)
 		}
+	}
+
+	m := newPositionalMatcher(positionalArgs, matched, c.CustomValueUnmarshallers)
+	if !m.match(0, 0) {
+		info := matched[m.firstErr.info]
+		errIndex = positionalIndices[m.firstErr.value]
+		return &ErrUnmarshallingArgument{
+			name:  argName(info.Field()),
+			value: positionalArgs[m.firstErr.value], error: m.firstErr.error}
+	}
 
-		for j := 0; j < numToTake; j++ {
-			res, err := info.Unmarshaller(c.CustomValueUnmarshallers)(positionalArgs[i], info.Field().Tag)
+	// values read from sources are only unmarshalled now that the assignment
+	// is known, so they may still fail
+	i := 0
+	for k, info := range matched {
+		for j := 0; j < m.counts[k]; j++ {
+			res, err := m.unmarshal(k, i)
 			if err != nil {
-				return &ErrUnmarshallingArgument{
-					name:  strings.ToUpper(info.Field().Name),
+				errIndex = positionalIndices[i]
+				return &ErrUnmarshallingArgument{name: argName(info.Field()),
 					value: positionalArgs[i], error: err}
 			}
-
-			i++
 			info.Update(args, res)
+			i++
 		}
 	}
 
-	if c.DefaultFlags != nil {
-		for _, flag := range allFlags {
-			flag.SetDefaultIfUnset(flags, c.DefaultFlags, c.CustomValueUnmarshallers)
+	res.Args = reflect.Indirect(args)
+
+	return nil
+}
+
+func hasDigitShort(validShort map[rune]*flagInfo) bool {
+	for r := range validShort {
+		if unicode.IsDigit(r) {
+			return true
 		}
 	}
 
-	reflect.ValueOf(c.Function).Call([]reflect.Value{
-		reflect.Indirect(flags), reflect.Indirect(args)})
+	return false
+}
 
-	return nil
+// isNumber reports whether arg is a negative decimal number, such as -5, -3.2
+// or -1e3.
+func isNumber(arg string) bool {
+	if len(arg) < 2 || !(unicode.IsDigit(rune(arg[1])) || arg[1] == '.') {
+		return false
+	}
+
+	_, err := strconv.ParseFloat(arg, 64)
+	return err == nil
+}
+
+// acceptsNumber reports whether any of the arguments that the positional
+// argument at index n could be assigned to is numeric.
+func acceptsNumber(argInfo []argInfo, n int) bool {
+	minBefore, maxBefore := 0, 0
+	for _, info := range argInfo {
+		if _, ok := info.(*passthroughArgInfo); ok {
+			continue
+		}
+
+		if info.Max() > 0 && n >= minBefore && n-maxBefore < info.Max() &&
+			isNumeric(argType(info)) {
+			return true
+		}
+
+		minBefore += info.Min()
+		maxBefore = addMax(maxBefore, info.Max())
+	}
+
+	return false
+}
+
+// addMax adds two maximum argument counts, either of which may be
+// math.MaxInt for unbounded arguments, without overflowing.
+func addMax(a, b int) int {
+	if b > math.MaxInt-a {
+		return math.MaxInt
+	}
+
+	return a + b
+}
+
+// argType returns the type of the individual values assigned to info.
+func argType(info argInfo) reflect.Type {
+	switch info.(type) {
+	case *sliceArgInfo, *arrayArgInfo:
+		return info.Field().Type.Elem()
+	default:
+		return info.Field().Type
+	}
 }
 
-func trySalvageBuiltinLong(c Cmd, flagName string, parentNames []string) error {
+func isNumeric(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func trySalvageBuiltinLong(c Cmd, flagName string, res *ParseResult,
+	remaining []string) error {
 	if flagName == "help" {
-		c.PrintHelp(parentNames)
+		res.Help = true
+		res.LongHelp = true
+		res.Remaining = remaining
 		return nil
 	} else if flagName == "version" && c.Version != "" {
-		println(c.Version)
+		res.Version = true
+		res.Remaining = remaining
+		return nil
+	} else if flagName == "gah-spec" {
+		res.Spec = true
+		res.Remaining = remaining
 		return nil
 	} else {
 		return unexpectedLong(flagName)
 	}
 }
 
-func trySalvageBuiltinShort(c Cmd, flagRune rune, parentNames []string) error {
+func trySalvageBuiltinShort(c Cmd, flagRune rune, res *ParseResult,
+	remaining []string) error {
 	if flagRune == 'h' {
-		c.PrintHelp(parentNames)
+		res.Help = true
+		res.Remaining = remaining
 		return nil
 	} else if flagRune == 'v' && c.Version != "" {
-		println(c.Version)
+		res.Version = true
+		res.Remaining = remaining
 		return nil
 	} else {
 		return unexpectedShort(flagRune)
 	}
 }
 
+func (r *ParseResult) warnIfDeprecated(flag *flagInfo, name string) {
+	message, found := flag.field.Tag.Lookup("deprecated")
+	if found {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("flag %s is deprecated: %s",
+			name, message))
+	}
+}
+
+func (r *ParseResult) finishFlags(index int, c Cmd, flags []flagInfo) {
+	set := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		set[flag.field.Name] = flag.set
+	}
+	r.Chain[index].Set = set
+
+	if c.DefaultFlags != nil {
+		for _, flag := range flags {
+			flag.SetDefaultIfUnset(r.Chain[index].Flags.Addr(), c.DefaultFlags,
+				c.CustomValueUnmarshallers)
+		}
+	}
+}
+
+func childNames(parentNames []string, name string) []string {
+	names := make([]string, len(parentNames), len(parentNames)+1)
+	copy(names, parentNames)
+	return append(names, name)
+}
+
 type flagInfo struct {
-	field reflect.StructField
-	set   bool
+	field       reflect.StructField
+	set         bool
+	shortPolicy ShortFlagPolicy
 }
 
 func (i *flagInfo) SetDefaultIfUnset(f reflect.Value, d interface{}, c unmarshal.CustomValueUnmarshallers) {
@@ -352,12 +821,27 @@ func (i *flagInfo) SetDefaultIfUnset(f reflect.Value, d interface{}, c unmarshal
 	i.set = true
 }
 
-func getFlags(flagsType reflect.Type) []flagInfo {
-	visibleFields := reflect.VisibleFields(flagsType)
-	flagInfoItems := make([]flagInfo, len(visibleFields))
+// defaultString formats the default value of the flag, taken from defaults if
+// the corresponding field is non-zero.
+func (i *flagInfo) defaultString(defaults interface{}) string {
+	if defaults == nil {
+		return ""
+	}
+
+	v := reflect.ValueOf(defaults).FieldByIndex(i.field.Index)
+	if v.IsZero() {
+		return ""
+	}
+
+	return fmt.Sprint(v.Interface())
+}
+
+func getFlags(flagsType reflect.Type, shortPolicy ShortFlagPolicy) []flagInfo {
+	fields := FlagFields(flagsType)
+	flagInfoItems := make([]flagInfo, len(fields))
 
-	for i, field := range visibleFields {
-		flagInfoItems[i] = flagInfo{field: field}
+	for i, field := range fields {
+		flagInfoItems[i] = flagInfo{field: field, shortPolicy: shortPolicy}
 	}
 
 	return flagInfoItems
@@ -368,45 +852,76 @@ func getFlagMaps(flags []flagInfo) (map[rune]*flagInfo, map[string]*flagInfo) {
 	validLong := make(map[string]*flagInfo)
 
 	for i := range flags {
-		short, found := flags[i].field.Tag.Lookup("short")
-		if found {
-			runes := []rune(short)
-			validShort[runes[0]] = &flags[i]
-		} else {
-			validShort[unicode.ToLower([]rune(flags[i].field.Name)[0])] = &flags[i]
+		for _, short := range flags[i].Shorts() {
+			validShort[short] = &flags[i]
 		}
-
-		long, found := flags[i].field.Tag.Lookup("long")
-		if found {
+		for _, long := range flags[i].Longs() {
 			validLong[long] = &flags[i]
-		} else {
-			validLong[pascalToKebab(flags[i].field.Name)] = &flags[i]
 		}
 	}
 
 	return validShort, validLong
 }
 
-func pascalToKebab(s string) string {
-	if len(s) == 0 {
-		return ""
+// Shorts returns every short name of the flag, with the primary one first,
+// according to its tags and the command's ShortFlagPolicy.
+func (i *flagInfo) Shorts() []rune {
+	if i.shortPolicy == ShortFlagsNone {
+		return nil
 	}
 
-	runes := []rune(s)
-	res := []rune{unicode.ToLower(runes[0])}
-	runes = runes[1:]
+	short, found := i.field.Tag.Lookup("short")
+	if found {
+		if short == "-" {
+			return nil
+		}
 
-	for _, r := range runes {
-		if unicode.IsUpper(r) {
-			res = append(res, '-', unicode.ToLower(r))
-		} else if unicode.IsDigit(r) {
-			res = append(res, '-', r)
-		} else {
-			res = append(res, r)
+		var shorts []rune
+		for _, s := range strings.Split(short, ",") {
+			shorts = append(shorts, []rune(s)[0])
 		}
+
+		return shorts
+	}
+
+	if i.shortPolicy == ShortFlagsExplicit {
+		return nil
+	}
+
+	return []rune{unicode.ToLower([]rune(i.field.Name)[0])}
+}
+
+// Longs returns every long name of the flag, including those from its
+// aliases tag, with the primary one first.
+func (i *flagInfo) Longs() []string {
+	var longs []string
+	long, found := i.field.Tag.Lookup("long")
+	if found {
+		longs = strings.Split(long, ",")
+	} else {
+		longs = []string{fields.PascalToKebab(i.field.Name)}
+	}
+
+	aliases, found := i.field.Tag.Lookup("aliases")
+	if found {
+		longs = append(longs, strings.Split(aliases, ",")...)
+	}
+
+	return longs
+}
+
+func (i *flagInfo) Long() string {
+	return i.Longs()[0]
+}
+
+// Placeholder returns the placeholder shown for the flag's value in help,
+// taken from its placeholder tag or otherwise its upper cased field name.
+func (i *flagInfo) Placeholder() string {
+	if placeholder, found := i.field.Tag.Lookup("placeholder"); found {
+		return placeholder
 	}
 
-	return string(res)
+	return strings.ToUpper(i.field.Name)
 }
 
 type argInfo interface {
@@ -506,10 +1021,53 @@ func (i *defaultArgInfo) Update(f reflect.Value, v reflect.Value) {
 	i.set = true
 }
 
+// passthroughArgInfo is the info for the field tagged with subcommandArgs,
+// which receives the arguments following the point where flag parsing stops
+// verbatim, rather than any positional arguments.
+type passthroughArgInfo struct {
+	field reflect.StructField
+}
+
+func (i *passthroughArgInfo) Min() int { return 0 }
+
+func (i *passthroughArgInfo) Max() int { return math.MaxInt }
+
+func (i *passthroughArgInfo) Field() reflect.StructField { return i.field }
+
+func (i *passthroughArgInfo) Unmarshaller(c unmarshal.CustomValueUnmarshallers,
+) func(string, reflect.StructTag) (reflect.Value, error) {
+	return func(s string, _ reflect.StructTag) (reflect.Value, error) {
+		return reflect.ValueOf(s), nil
+	}
+}
+
+func (i *passthroughArgInfo) Update(f reflect.Value, v reflect.Value) {
+	f.Elem().FieldByIndex(i.field.Index).Set(v)
+}
+
+func (i *passthroughArgInfo) Optional() bool { return true }
+
+func (i *passthroughArgInfo) Multiple() bool { return true }
+
+func hasPassthroughArg(argsType reflect.Type) bool {
+	for _, field := range reflect.VisibleFields(argsType) {
+		if _, found := field.Tag.Lookup("subcommandArgs"); found {
+			return true
+		}
+	}
+
+	return false
+}
+
 func getArgs(argsType reflect.Type) []argInfo {
 	var argInfoItems = make([]argInfo, len(reflect.VisibleFields(argsType)))
 
 	for i, field := range reflect.VisibleFields(argsType) {
+		if _, found := field.Tag.Lookup("subcommandArgs"); found {
+			argInfoItems[i] = &passthroughArgInfo{field: field}
+			continue
+		}
+
 		switch field.Type.Kind() {
 		case reflect.Slice:
 			if !unmarshal.ElementWise(field) {
@@ -553,57 +1111,55 @@ func getArgs(argsType reflect.Type) []argInfo {
 	return argInfoItems
 }
 
-func (c Cmd) PrintHelp(parentNames []string) {
-	println(strings.Join(append(parentNames, c.Name), "-") + " " + c.Version)
-	if c.Author != "" {
-		println(c.Author)
+// usage returns the usage line for c, consisting of its full name followed by
+// either a subcommand placeholder or its arguments.
+func (c Cmd) usage(parentNames []string) string {
+	usage := strings.Join(childNames(parentNames, c.Name), " ")
+	if c.Subcommands != nil {
+		return usage + " [SUBCOMMAND]"
 	}
-	if c.Description != "" {
-		println(c.Description)
+
+	if c.Function == nil {
+		return usage
 	}
-	if c.Subcommands != nil {
-		println("\nUSAGE:\n\t" + strings.Join(append(parentNames, c.Name), " ") + " [SUBCOMMAND]")
-		if c.Version == "" {
-			println("\nFLAGS:\n\t-h, --help Prints help information")
-		} else {
-			println("\nFLAGS:\n\t-h, --help    Prints help information\n\t-v, --version Prints version information")
-		}
-		println("\nSUBCOMMANDS:")
-		maxSubcommandNameLength := 0
-		for _, subcommand := range c.Subcommands {
-			l := len(strings.Join(
-				append([]string{subcommand.Name}, subcommand.Aliases...), ", "))
-			if l > maxSubcommandNameLength {
-				maxSubcommandNameLength = l
+
+	args := getArgs(reflect.TypeOf(c.Function).In(1))
+	for _, arg := range args {
+		placeholder := argPlaceholder(arg.Field())
+		if arg.Optional() {
+			if arg.Multiple() {
+				usage += " [..." + placeholder + "]"
+			} else {
+				usage += " [" + placeholder + "]"
 			}
-		}
-		for _, subcommand := range c.Subcommands {
-			s := strings.Join(append([]string{subcommand.Name},
-				subcommand.Aliases...), ", ")
-			l := len(s)
-			println("\t" + s + strings.Repeat(" ",
-				1+maxSubcommandNameLength-l) + subcommand.Description)
-		}
-	} else {
-		print("\nUSAGE:\n\t" + c.Name)
-		args := getArgs(reflect.TypeOf(c.Function).In(1))
-		for _, arg := range args {
-			if arg.Optional() {
-				if arg.Multiple() {
-					print(" [..." + strings.ToUpper(arg.Field().Name) + "]")
-				} else {
-					print(" [" + strings.ToUpper(arg.Field().Name) + "]")
-				}
+		} else {
+			if arg.Multiple() {
+				usage += " ..." + placeholder
 			} else {
-				if arg.Multiple() {
-					print(" ..." + strings.ToUpper(arg.Field().Name))
-				} else {
-					print(" " + strings.ToUpper(arg.Field().Name))
-				}
+				usage += " " + placeholder
 			}
 		}
-		// to ensure there's a new line at the end of the usage line
-		println()
-		// TODO: print flags
 	}
+
+	return usage
+}
+
+// argName returns the name an argument is referred to by in help and errors,
+// taken from its name tag or otherwise its upper cased field name.
+func argName(field reflect.StructField) string {
+	if name, found := field.Tag.Lookup("name"); found {
+		return name
+	}
+
+	return strings.ToUpper(field.Name)
+}
+
+// argPlaceholder returns the placeholder shown for an argument in usage lines,
+// taken from its placeholder tag or otherwise its name.
+func argPlaceholder(field reflect.StructField) string {
+	if placeholder, found := field.Tag.Lookup("placeholder"); found {
+		return placeholder
+	}
+
+	return argName(field)
 }
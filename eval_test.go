@@ -1,7 +1,11 @@
 package gah
 
 import (
+	"bytes"
+	"errors"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -138,7 +142,12 @@ func TestArgs(t *testing.T) {
 	assert.Equal(t, test1, "value1")
 	assert.Equal(t, test2, []int{-5})
 	assert.Equal(t, test3, [3]string{"a", "b", "c"})
+	// four values can't satisfy the five required, so this is reported as a
+	// missing Test3 value before anything is unmarshalled; with a fifth, "a"
+	// is assigned to Test2 and fails to unmarshal as an int
 	assert.ErrorIs(t, cmd.Eval([]string{"", "value1", "a", "b", "c"},
+		[]string{}), &ErrExpectedArgumentValue{})
+	assert.ErrorIs(t, cmd.Eval([]string{"", "value1", "a", "b", "c", "d"},
 		[]string{}), &ErrUnmarshallingArgument{})
 }
 
@@ -176,3 +185,524 @@ func TestCustomUnmarshallers(t *testing.T) {
 	assert.True(t, !test2)
 	assert.True(t, !test3)
 }
+
+func TestParse(t *testing.T) {
+	called := false
+
+	type flags struct {
+		Test1 int
+		Test2 string
+	}
+	cmd := Cmd{
+		Name: "root",
+		Subcommands: []Cmd{
+			{
+				Name: "sub",
+				Function: func(f flags, a struct {
+					Arg string
+				}) {
+					called = true
+				},
+				DefaultFlags: flags{Test2: "default"},
+			},
+		},
+	}
+
+	res, err := cmd.Parse([]string{"", "sub", "--test-1", "3", "value"}, nil)
+	assert.NoError(t, err)
+	assert.False(t, called)
+	assert.Len(t, res.Chain, 2)
+	assert.Equal(t, "root", res.Chain[0].Cmd.Name)
+	assert.Equal(t, "sub", res.Leaf().Cmd.Name)
+	assert.Equal(t, []string{"root"}, res.Leaf().ParentNames)
+	assert.Equal(t, flags{Test1: 3, Test2: "default"}, res.Leaf().Flags.Interface())
+	assert.Equal(t, map[string]bool{"Test1": true, "Test2": false}, res.Leaf().Set)
+	assert.Equal(t, "value", res.Args.FieldByName("Arg").Interface())
+
+	assert.NoError(t, res.Run())
+	assert.True(t, called)
+
+	res, err = cmd.Parse([]string{"", "sub", "--help", "extra", "args"}, nil)
+	assert.NoError(t, err)
+	assert.True(t, res.Help)
+	assert.Equal(t, []string{"extra", "args"}, res.Remaining)
+}
+
+func TestRunReturnsError(t *testing.T) {
+	expected := errors.New("test error")
+
+	cmd := Cmd{
+		Function: func(_ struct{}, _ struct{}) error {
+			return expected
+		},
+	}
+
+	assert.ErrorIs(t, cmd.Eval([]string{""}, nil), expected)
+}
+
+func TestHooks(t *testing.T) {
+	var calls []string
+	var verbose bool
+
+	type rootFlags struct {
+		Verbose bool
+	}
+
+	record := func(name string) Hook {
+		return func(flags interface{}, _ *ParseResult) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	middleware := func(name string) Middleware {
+		return func(next Runner) Runner {
+			return func(r *ParseResult) error {
+				calls = append(calls, name+"-before")
+				err := next(r)
+				calls = append(calls, name+"-after")
+				return err
+			}
+		}
+	}
+
+	cmd := Cmd{
+		Function: func(f rootFlags, _ struct{}) {
+			calls = append(calls, "root")
+		},
+		PreRun: func(flags interface{}, _ *ParseResult) error {
+			verbose = flags.(rootFlags).Verbose
+			calls = append(calls, "root-pre")
+			return nil
+		},
+		PostRun:    record("root-post"),
+		Middleware: []Middleware{middleware("outer"), middleware("inner")},
+		Subcommands: []Cmd{
+			{
+				Name: "sub",
+				Function: func(_ struct{}, _ struct{}) {
+					calls = append(calls, "sub")
+				},
+				PreRun:  record("sub-pre"),
+				PostRun: record("sub-post"),
+			},
+		},
+	}
+
+	assert.NoError(t, cmd.Eval([]string{"", "--verbose", "sub"}, nil))
+	assert.Equal(t, []string{"outer-before", "inner-before", "root-pre", "sub-pre",
+		"sub", "sub-post", "root-post", "inner-after", "outer-after"}, calls)
+	assert.True(t, verbose)
+
+	calls = nil
+	expected := errors.New("test error")
+	cmd.Subcommands[0].PreRun = func(_ interface{}, _ *ParseResult) error {
+		return expected
+	}
+
+	assert.ErrorIs(t, cmd.Eval([]string{"", "sub"}, nil), expected)
+	assert.Equal(t, []string{"outer-before", "inner-before", "root-pre",
+		"inner-after", "outer-after"}, calls)
+}
+
+func TestExitCodes(t *testing.T) {
+	cmd := Cmd{
+		Name: "root",
+		Subcommands: []Cmd{
+			{
+				Name: "sub",
+				Function: func(_ struct{}, a struct {
+					Arg int
+				}) error {
+					if a.Arg < 0 {
+						return errors.New("negative")
+					}
+					return nil
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	assert.Equal(t, 0, cmd.evalWithExitCode([]string{"", "sub", "1"}, &buf, false))
+	assert.Empty(t, buf.String())
+
+	buf.Reset()
+	assert.Equal(t, ExitFailure, cmd.evalWithExitCode(
+		[]string{"", "sub", "--", "-1"}, &buf, false))
+	assert.Equal(t, "negative\n", buf.String())
+
+	buf.Reset()
+	assert.Equal(t, ExitUsage, cmd.evalWithExitCode(
+		[]string{"", "sub", "a"}, &buf, false))
+	assert.Contains(t, buf.String(), "usage: root sub ARG\n")
+	assert.Contains(t, buf.String(), "see 'root sub --help'")
+	assert.NotContains(t, buf.String(), "\033[")
+
+	buf.Reset()
+	assert.Equal(t, ExitUsage, cmd.evalWithExitCode(
+		[]string{"", "other"}, &buf, true))
+	assert.Contains(t, buf.String(), "\033[31minvalid subcommand other\033[0m")
+	assert.Contains(t, buf.String(), "usage: root [SUBCOMMAND]\n")
+}
+
+func TestErrorAccessors(t *testing.T) {
+	cmd := Cmd{
+		Function: func(f struct {
+			Count int
+		}, _ struct{}) {
+		},
+	}
+
+	err := cmd.Eval([]string{"", "--count", "a"}, nil)
+	var unmarshallingErr *ErrUnmarshallingFlagValue
+	assert.ErrorAs(t, err, &unmarshallingErr)
+	assert.Equal(t, "--count", unmarshallingErr.Flag())
+	assert.Error(t, unmarshallingErr.Unwrap())
+
+	var usageErr UsageError
+	assert.ErrorAs(t, err, &usageErr)
+}
+
+func TestDeprecatedAndHiddenFlags(t *testing.T) {
+	var output string
+
+	cmd := Cmd{
+		Function: func(f struct {
+			Output    string `aliases:"out,o-file"`
+			OldOutput string `short:"O" hidden:"" deprecated:"use --output instead"`
+		}, _ struct{}) {
+			output = f.Output + f.OldOutput
+		},
+	}
+
+	res, err := cmd.Parse([]string{"", "--out", "a"}, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, res.Warnings)
+	assert.NoError(t, res.Run())
+	assert.Equal(t, "a", output)
+
+	var buf bytes.Buffer
+	cmd.Stderr = &buf
+	assert.NoError(t, cmd.Eval([]string{"", "-O", "b"}, nil))
+	assert.Equal(t, "b", output)
+	assert.Equal(t, "warning: flag -O is deprecated: use --output instead\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, cmd.Eval([]string{"", "--help"}, nil))
+	assert.Contains(t, buf.String(), "-o, --output, --out, --o-file=OUTPUT")
+	assert.NotContains(t, buf.String(), "-O")
+}
+
+func TestMultipleFlagNames(t *testing.T) {
+	var output string
+
+	cmd := Cmd{
+		Function: func(f struct {
+			Output string `short:"o,O" long:"output,out" aliases:"o-file"`
+		}, _ struct{}) {
+			output = f.Output
+		},
+	}
+
+	for _, args := range [][]string{
+		{"", "-o", "a"}, {"", "-O", "a"}, {"", "--output", "a"}, {"", "--out=a"},
+		{"", "--o-file", "a"},
+	} {
+		output = ""
+		assert.NoError(t, cmd.Eval(args, nil))
+		assert.Equal(t, "a", output)
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, cmd.WriteHelp(&buf, nil, true))
+	assert.Contains(t, buf.String(), "-o, -O, --output, --out, --o-file=OUTPUT")
+}
+
+func TestShortFlagPolicy(t *testing.T) {
+	var verbose, version bool
+
+	function := func(f struct {
+		Verbose bool `short:"V"`
+		Version bool
+		Quiet   bool `short:"-"`
+	}, _ struct{}) {
+		verbose = f.Verbose
+		version = f.Version
+	}
+
+	cmd := Cmd{Function: function}
+	assert.NoError(t, cmd.Eval([]string{"", "-V", "-v"}, nil))
+	assert.True(t, verbose)
+	assert.True(t, version)
+	assert.ErrorIs(t, cmd.Eval([]string{"", "-q"}, nil), &ErrUnexpectedFlag{})
+	assert.NoError(t, cmd.Eval([]string{"", "--quiet"}, nil))
+
+	cmd = Cmd{Function: function, ShortFlags: ShortFlagsExplicit}
+	assert.NoError(t, cmd.Eval([]string{"", "-V"}, nil))
+	assert.True(t, verbose)
+	assert.ErrorIs(t, cmd.Eval([]string{"", "-v"}, nil), &ErrUnexpectedFlag{})
+
+	cmd = Cmd{Function: function, ShortFlags: ShortFlagsNone}
+	assert.ErrorIs(t, cmd.Eval([]string{"", "-V"}, nil), &ErrUnexpectedFlag{})
+	assert.NoError(t, cmd.Eval([]string{"", "--verbose", "--version"}, nil))
+
+	var buf bytes.Buffer
+	assert.NoError(t, cmd.WriteHelp(&buf, nil, true))
+	assert.Contains(t, buf.String(), "\n\t--verbose\n")
+}
+
+func TestParseMode(t *testing.T) {
+	var verbose bool
+	var program []string
+
+	positional := func(f struct{ Verbose bool }, a struct{ Program []string }) {
+		verbose = f.Verbose
+		program = a.Program
+	}
+
+	cmd := Cmd{Function: positional}
+	assert.NoError(t, cmd.Eval([]string{"", "ls", "-v"}, nil))
+	assert.True(t, verbose)
+	assert.Equal(t, []string{"ls"}, program)
+	assert.NoError(t, cmd.Eval([]string{"", "--", "ls", "-v"}, nil))
+	assert.False(t, verbose)
+	assert.Equal(t, []string{"ls", "-v"}, program)
+
+	cmd = Cmd{Function: positional, ParseMode: ParsePOSIX}
+	assert.NoError(t, cmd.Eval([]string{"", "-v", "ls", "-l", "--", "x"}, nil))
+	assert.True(t, verbose)
+	assert.Equal(t, []string{"ls", "-l", "--", "x"}, program)
+
+	var rest []string
+
+	passthrough := func(f struct{ Verbose bool }, a struct {
+		Program string
+		Rest    []string `subcommandArgs:""`
+	}) {
+		verbose = f.Verbose
+		program = []string{a.Program}
+		rest = a.Rest
+	}
+
+	cmd = Cmd{Function: passthrough, ParseMode: ParsePassthrough}
+	assert.NoError(t, cmd.Eval([]string{"", "-v", "ls", "-l", "--", "x"}, nil))
+	assert.True(t, verbose)
+	assert.Equal(t, []string{"ls"}, program)
+	assert.Equal(t, []string{"-l", "--", "x"}, rest)
+
+	cmd = Cmd{Function: passthrough, ParseMode: ParsePOSIX}
+	assert.ErrorIs(t, cmd.Eval([]string{"", "-v", "a", "-b", "c"}, nil), &ErrUnexpectedArgument{})
+
+	both := func(f struct{ Verbose bool }, a struct {
+		Program []string
+		Rest    []string `subcommandArgs:""`
+	}) {
+		program = a.Program
+		rest = a.Rest
+	}
+
+	cmd = Cmd{Function: both, ParseMode: ParsePOSIX}
+	assert.NoError(t, cmd.Eval([]string{"", "ls", "-l"}, nil))
+	assert.Equal(t, []string{"ls", "-l"}, program)
+	assert.Empty(t, rest)
+
+	cmd = Cmd{Function: both, ParseMode: ParsePassthrough}
+	assert.NoError(t, cmd.Eval([]string{"", "ls", "-l"}, nil))
+	assert.Equal(t, []string{"ls"}, program)
+	assert.Equal(t, []string{"-l"}, rest)
+
+	cmd = Cmd{Function: passthrough}
+	assert.NoError(t, cmd.Eval([]string{"", "ls", "-v", "--", "-l"}, nil))
+	assert.True(t, verbose)
+	assert.Equal(t, []string{"-l"}, rest)
+	assert.ErrorIs(t, cmd.Eval([]string{"", "ls", "-l"}, nil), &ErrUnexpectedFlag{})
+
+	assert.True(t, cmd.ArgSpecs()[1].Passthrough)
+}
+
+func TestNegativeNumbers(t *testing.T) {
+	var name string
+	var numbers []float64
+	var verbose bool
+
+	cmd := Cmd{
+		Function: func(f struct{ Verbose bool }, a struct {
+			Name    string
+			Numbers []float64
+		}) {
+			name = a.Name
+			numbers = a.Numbers
+			verbose = f.Verbose
+		},
+		NegativeNumbers: true,
+	}
+
+	assert.NoError(t, cmd.Eval([]string{"", "value1", "-5", "-v", "-3.2", "-1e3"}, nil))
+	assert.Equal(t, "value1", name)
+	assert.Equal(t, []float64{-5, -3.2, -1e3}, numbers)
+	assert.True(t, verbose)
+	assert.ErrorIs(t, cmd.Eval([]string{"", "-5"}, nil), &ErrUnexpectedFlag{})
+	assert.ErrorIs(t, cmd.Eval([]string{"", "value1", "-inf"}, nil), &ErrUnexpectedFlag{})
+
+	cmd.NegativeNumbers = false
+	assert.ErrorIs(t, cmd.Eval([]string{"", "value1", "-5"}, nil), &ErrUnexpectedFlag{})
+
+	var one bool
+	cmd = Cmd{
+		Function: func(f struct {
+			One bool `short:"1"`
+		}, a struct{ Numbers []int }) {
+			one = f.One
+			numbers = nil
+			for _, n := range a.Numbers {
+				numbers = append(numbers, float64(n))
+			}
+		},
+		NegativeNumbers: true,
+	}
+
+	assert.NoError(t, cmd.Eval([]string{"", "2", "-1"}, nil))
+	assert.True(t, one)
+	assert.Equal(t, []float64{2}, numbers)
+}
+
+func TestOptionalValue(t *testing.T) {
+	var color string
+	var args []string
+
+	cmd := Cmd{
+		Function: func(f struct {
+			Color string `optionalVal:"auto" choices:"auto,always,never"`
+		}, a struct{ Args []string }) {
+			color = f.Color
+			args = a.Args
+		},
+	}
+
+	assert.NoError(t, cmd.Eval([]string{"", "--color", "always"}, nil))
+	assert.Equal(t, "auto", color)
+	assert.Equal(t, []string{"always"}, args)
+	assert.NoError(t, cmd.Eval([]string{"", "--color=never"}, nil))
+	assert.Equal(t, "never", color)
+	assert.NoError(t, cmd.Eval([]string{"", "-c"}, nil))
+	assert.Equal(t, "auto", color)
+	assert.NoError(t, cmd.Eval([]string{"", "-calways"}, nil))
+	assert.Equal(t, "always", color)
+	assert.NoError(t, cmd.Eval([]string{"", "-c=never"}, nil))
+	assert.Equal(t, "never", color)
+	assert.ErrorIs(t, cmd.Eval([]string{"", "--color=sometimes"}, nil),
+		&ErrUnmarshallingFlagValue{})
+
+	var buf bytes.Buffer
+	assert.NoError(t, cmd.WriteHelp(&buf, nil, true))
+	assert.Contains(t, buf.String(), "-c, --color[=COLOR]")
+}
+
+func TestPositionalMatching(t *testing.T) {
+	var sources []string
+	var dest string
+
+	cmd := Cmd{
+		Function: func(_ struct{}, a struct {
+			Sources []string `min:"1"`
+			Dest    string
+		}) {
+			sources = a.Sources
+			dest = a.Dest
+		},
+	}
+	assert.NoError(t, cmd.Eval([]string{"", "a", "b", "c"}, nil))
+	assert.Equal(t, []string{"a", "b"}, sources)
+	assert.Equal(t, "c", dest)
+	assert.ErrorIs(t, cmd.Eval([]string{"", "a"}, nil), &ErrExpectedArgumentValue{})
+
+	var first, last string
+	var middle []string
+
+	cmd = Cmd{
+		Function: func(_ struct{}, a struct {
+			First  string
+			Middle []string `max:"1"`
+			Last   string
+		}) {
+			first, middle, last = a.First, a.Middle, a.Last
+		},
+	}
+	assert.NoError(t, cmd.Eval([]string{"", "a", "b"}, nil))
+	assert.Equal(t, "a", first)
+	assert.Empty(t, middle)
+	assert.Equal(t, "b", last)
+	assert.NoError(t, cmd.Eval([]string{"", "a", "b", "c"}, nil))
+	assert.Equal(t, []string{"b"}, middle)
+	assert.Equal(t, "c", last)
+
+	var numbers []int
+	var names []string
+
+	typed := func(_ struct{}, a struct {
+		Numbers []int `greedy:""`
+		Names   []string
+	}) {
+		numbers, names = a.Numbers, a.Names
+	}
+
+	cmd = Cmd{Function: typed}
+	assert.NoError(t, cmd.Eval([]string{"", "1", "2", "a", "3"}, nil))
+	assert.Equal(t, []int{1, 2}, numbers)
+	assert.Equal(t, []string{"a", "3"}, names)
+
+	cmd = Cmd{Function: func(_ struct{}, a struct {
+		Numbers []int `lazy:""`
+		Names   []string
+	}) {
+		numbers, names = a.Numbers, a.Names
+	}}
+	assert.NoError(t, cmd.Eval([]string{"", "1", "2"}, nil))
+	assert.Empty(t, numbers)
+	assert.Equal(t, []string{"1", "2"}, names)
+
+	cmd = Cmd{Function: func(_ struct{}, a struct {
+		Numbers []int `min:"1"`
+		Count   int
+	}) {
+	}}
+	err := cmd.Eval([]string{"", "1", "x"}, nil)
+	assert.ErrorIs(t, err, &ErrUnmarshallingArgument{})
+	assert.Equal(t, "COUNT", err.(*ErrUnmarshallingArgument).Name())
+
+	stdin := unmarshal.Stdin
+	defer func() { unmarshal.Stdin = stdin }()
+	in := strings.NewReader("input")
+	unmarshal.Stdin = in
+
+	var words []string
+	var input string
+	var counts []int
+
+	cmd = Cmd{Function: func(_ struct{}, a struct {
+		Words  []string `lazy:""`
+		Input  string   `stdin:""`
+		Counts []int    `min:"1"`
+	}) {
+		words, input, counts = a.Words, a.Input, a.Counts
+	}}
+	// the assignment of - to Input is tried and abandoned first, which
+	// mustn't consume stdin
+	assert.NoError(t, cmd.Eval([]string{"", "-", "x", "5"}, nil))
+	assert.Equal(t, []string{"-"}, words)
+	assert.Equal(t, "x", input)
+	assert.Equal(t, []int{5}, counts)
+	assert.Equal(t, 5, in.Len())
+	assert.NoError(t, cmd.Eval([]string{"", "-", "5"}, nil))
+	assert.Empty(t, words)
+	assert.Equal(t, "input", input)
+
+	cmd = Cmd{Function: func(_ struct{}, a struct {
+		Input string `fromFile:""`
+	}) {
+	}}
+	err = cmd.Eval([]string{"", "@" + filepath.Join(t.TempDir(), "missing")}, nil)
+	assert.ErrorIs(t, err, &ErrUnmarshallingArgument{})
+	assert.Equal(t, "INPUT", err.(*ErrUnmarshallingArgument).Name())
+}
@@ -0,0 +1,87 @@
+package gah
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"mtoohey.com/gah/internal/fields"
+)
+
+// FlagFields returns the fields of flagsType that are flags. Named struct
+// fields tagged with prefix are flag groups, whose fields are flags with the
+// prefix prepended to each of their long names, such as --db-host for a Host
+// field in a group tagged with prefix:"db-". Flags in groups are listed under
+// a heading in help, taken from the group's group tag or otherwise its field
+// name, and only have short names if they are tagged with short.
+//
+// The returned fields of flags in groups have an Index relative to
+// flagsType, a Name that is the path to the field joined with dots, such as
+// "DB.Host", and tags that reflect the above.
+func FlagFields(flagsType reflect.Type) []reflect.StructField {
+	return flagFields(flagsType, nil, "", "", "")
+}
+
+func flagFields(t reflect.Type, index []int, namePrefix, prefix, group string) []reflect.StructField {
+	var fields []reflect.StructField
+
+	for _, field := range reflect.VisibleFields(t) {
+		// the fields of embedded structs are promoted, so are already visible
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			continue
+		}
+
+		field.Index = append(append([]int{}, index...), field.Index...)
+
+		fieldPrefix, isGroup := field.Tag.Lookup("prefix")
+		if isGroup && field.Type.Kind() == reflect.Struct {
+			fieldGroup := field.Tag.Get("group")
+			if fieldGroup == "" {
+				fieldGroup = field.Name
+			}
+
+			fields = append(fields, flagFields(field.Type, field.Index,
+				namePrefix+field.Name+".", prefix+fieldPrefix, fieldGroup)...)
+			continue
+		}
+
+		if index != nil {
+			info := flagInfo{field: field}
+			longs := info.Longs()
+			for i := range longs {
+				longs[i] = prefix + longs[i]
+			}
+
+			field.Tag = setTag(field.Tag, "long", longs[0])
+			if len(longs) > 1 {
+				field.Tag = setTag(field.Tag, "aliases", strings.Join(longs[1:], ","))
+			}
+			if _, found := field.Tag.Lookup("short"); !found {
+				field.Tag = setTag(field.Tag, "short", "-")
+			}
+			if _, found := field.Tag.Lookup("placeholder"); !found {
+				field.Tag = setTag(field.Tag, "placeholder", strings.ToUpper(field.Name))
+			}
+			if _, found := field.Tag.Lookup("group"); !found {
+				field.Tag = setTag(field.Tag, "group", group)
+			}
+			field.Name = namePrefix + field.Name
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// setTag returns tag with key set to value, replacing any existing value.
+func setTag(tag reflect.StructTag, key string, value string) reflect.StructTag {
+	var pairs []string
+	for _, pair := range fields.TagPairs(tag) {
+		if pair[0] != key {
+			pairs = append(pairs, pair[0]+":"+strconv.Quote(pair[1]))
+		}
+	}
+
+	return reflect.StructTag(strings.Join(append(pairs, key+":"+strconv.Quote(value)), " "))
+}
@@ -0,0 +1,66 @@
+package gah
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type dbOptions struct {
+	Host string `help:"Database host."`
+	Port int
+}
+
+type groupedFlags struct {
+	Verbose bool
+	DB      dbOptions `prefix:"db-" group:"Database Options"`
+	Replica dbOptions `prefix:"replica-"`
+}
+
+func TestFlagGroups(t *testing.T) {
+	var flags groupedFlags
+
+	cmd := Cmd{
+		Name: "root",
+		Function: func(f groupedFlags, _ struct{}) {
+			flags = f
+		},
+		DefaultFlags: groupedFlags{DB: dbOptions{Host: "localhost", Port: 5432}},
+		Stderr:       &bytes.Buffer{},
+	}
+
+	assert.NoError(t, cmd.Eval([]string{"", "-v", "--db-port", "6543", "--replica-host", "r"}, nil))
+	assert.True(t, flags.Verbose)
+	assert.Equal(t, dbOptions{Host: "localhost", Port: 6543}, flags.DB)
+	assert.Equal(t, dbOptions{Host: "r"}, flags.Replica)
+	assert.ErrorIs(t, cmd.Eval([]string{"", "--host", "h"}, nil), &ErrUnexpectedFlag{})
+
+	res, err := cmd.Parse([]string{"", "--db-host", "h"}, nil)
+	assert.NoError(t, err)
+	assert.True(t, res.Chain[0].Set["DB.Host"])
+	assert.False(t, res.Chain[0].Set["Replica.Host"])
+
+	var buf bytes.Buffer
+	assert.NoError(t, cmd.WriteHelp(&buf, nil, true))
+	assert.Contains(t, buf.String(), `
+FLAGS:
+	-v, --verbose
+	-h, --help          Prints help information
+
+DATABASE OPTIONS:
+	--db-host=HOST      Database host.
+	--db-port=PORT
+`)
+	assert.Contains(t, buf.String(), "\nREPLICA:\n\t--replica-host=HOST")
+
+	assert.Equal(t, "DB.Port", cmd.FlagSpecs()[2].Field)
+	assert.Equal(t, "Database Options", cmd.FlagSpecs()[2].Group)
+}
+
+func TestSetTag(t *testing.T) {
+	tag := reflect.StructTag(`long:"old" help:"a \"quoted\" value"`)
+	assert.Equal(t, reflect.StructTag(`help:"a \"quoted\" value" long:"new"`), setTag(tag, "long", "new"))
+	assert.Equal(t, `a "quoted" value`, setTag(tag, "short", "-").Get("help"))
+}
@@ -0,0 +1,103 @@
+package gah
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"mtoohey.com/gah/internal/fields"
+)
+
+// FromStruct builds a command tree from root, which must be a struct or a
+// pointer to one, as an alternative to nesting Cmd literals. Each field of
+// root tagged with cmd is a subcommand, named by the tag or otherwise the
+// field's name in kebab case. Subcommand fields are themselves structs that
+// may have subcommand fields, and whose Run method, if they have one, is used
+// as the subcommand's Function. The following tags on subcommand fields set
+// the corresponding Cmd fields:
+//
+//	help        Description
+//	aliases     Aliases, separated by commas
+//	group       Group
+//	hidden      Hidden
+//	deprecated  Deprecated
+//
+// Fields of type Cmd tagged with cmd are used as they are, so commands
+// declared either way can be mixed. The returned command uses root's Run
+// method as its Function if it has one, and has no Name.
+func FromStruct(root interface{}) Cmd {
+	v := reflect.ValueOf(root)
+	if v.Kind() != reflect.Ptr {
+		// a copy is made so that methods with pointer receivers can be used
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		v = p
+	}
+
+	return fromStruct(v)
+}
+
+// fromStruct builds a command from v, which is a pointer to a struct.
+func fromStruct(v reflect.Value) Cmd {
+	if v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("FromStruct requires structs, got %s", v.Elem().Type()))
+	}
+
+	var c Cmd
+
+	if run := v.MethodByName("Run"); run.IsValid() {
+		c.Function = run.Interface()
+	}
+
+	t := v.Elem().Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, found := field.Tag.Lookup("cmd")
+		if !found {
+			continue
+		}
+
+		fieldValue := v.Elem().Field(i)
+
+		var subcommand Cmd
+		if field.Type == reflect.TypeOf(Cmd{}) {
+			subcommand = fieldValue.Interface().(Cmd)
+		} else {
+			if fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					fieldValue.Set(reflect.New(field.Type.Elem()))
+				}
+			} else {
+				fieldValue = fieldValue.Addr()
+			}
+
+			subcommand = fromStruct(fieldValue)
+		}
+
+		if name != "" {
+			subcommand.Name = name
+		} else if subcommand.Name == "" {
+			subcommand.Name = fields.PascalToKebab(field.Name)
+		}
+
+		if help, found := field.Tag.Lookup("help"); found {
+			subcommand.Description = help
+		}
+		if aliases, found := field.Tag.Lookup("aliases"); found {
+			subcommand.Aliases = strings.Split(aliases, ",")
+		}
+		if group, found := field.Tag.Lookup("group"); found {
+			subcommand.Group = group
+		}
+		if _, found := field.Tag.Lookup("hidden"); found {
+			subcommand.Hidden = true
+		}
+		if deprecated, found := field.Tag.Lookup("deprecated"); found {
+			subcommand.Deprecated = deprecated
+		}
+
+		c.Subcommands = append(c.Subcommands, subcommand)
+	}
+
+	return c
+}
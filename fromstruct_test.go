@@ -0,0 +1,61 @@
+package gah
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type structRoot struct {
+	Serve  serveCmd  `cmd:"" help:"Runs the server." aliases:"s"`
+	Remote remoteCmd `cmd:"remote" group:"Management"`
+	Legacy Cmd       `cmd:"old" deprecated:"use serve instead"`
+}
+
+type serveCmd struct {
+	port int
+}
+
+func (c *serveCmd) Run(f struct{ Port int }, _ struct{}) {
+	c.port = f.Port
+}
+
+type remoteCmd struct {
+	Add *remoteAddCmd `cmd:"add"`
+}
+
+type remoteAddCmd struct {
+	added []string
+}
+
+func (c *remoteAddCmd) Run(_ struct{}, a struct{ Names []string }) error {
+	c.added = a.Names
+	return nil
+}
+
+func TestFromStruct(t *testing.T) {
+	legacyCalled := false
+	root := &structRoot{Legacy: Cmd{Function: func(_ struct{}, _ struct{}) {
+		legacyCalled = true
+	}}}
+
+	cmd := FromStruct(root)
+	cmd.Name = "app"
+
+	assert.Len(t, cmd.Subcommands, 3)
+	assert.Equal(t, "serve", cmd.Subcommands[0].Name)
+	assert.Equal(t, "Runs the server.", cmd.Subcommands[0].Description)
+	assert.Equal(t, []string{"s"}, cmd.Subcommands[0].Aliases)
+	assert.Equal(t, "Management", cmd.Subcommands[1].Group)
+	assert.Nil(t, cmd.Subcommands[1].Function)
+	assert.Equal(t, "old", cmd.Subcommands[2].Name)
+
+	assert.NoError(t, cmd.Eval([]string{"", "s", "--port", "80"}, nil))
+	assert.Equal(t, 80, root.Serve.port)
+	assert.NoError(t, cmd.Eval([]string{"", "remote", "add", "a", "b"}, nil))
+	assert.Equal(t, []string{"a", "b"}, root.Remote.Add.added)
+	assert.NoError(t, cmd.Eval([]string{"", "old"}, nil))
+	assert.True(t, legacyCalled)
+
+	assert.Panics(t, func() { FromStruct(3) })
+}
@@ -1,11 +1,15 @@
 module mtoohey.com/gah
 
-go 1.17
+go 1.18
+
+require (
+	github.com/stretchr/testify v1.7.0
+	golang.org/x/term v0.1.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/objx v0.1.0 // indirect
-	github.com/stretchr/testify v1.7.0 // indirect
+	golang.org/x/sys v0.0.0-20210615035016-665e8c7367d1 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
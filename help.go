@@ -0,0 +1,344 @@
+package gah
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"golang.org/x/term"
+)
+
+// DefaultHelpTemplate is the template used to render help when a command's
+// HelpTemplate is empty. It is executed with a HelpData.
+const DefaultHelpTemplate = `{{.Name}}{{if .Version}} {{.Version}}{{end}}
+{{if .Author}}{{.Author}}
+{{end}}{{if .Description}}{{wrap 0 .Description}}
+{{end}}{{if and .Long .LongDescription}}
+{{wrap 0 .LongDescription}}
+{{end}}
+USAGE:
+	{{.Usage}}
+{{if .HasArgHelp}}
+ARGS:
+{{range .Args}}	{{row $.ArgWidth .Name .Help}}
+{{end}}{{end}}
+FLAGS:
+{{range .Flags}}	{{row $.FlagWidth .Names .Description}}
+{{end}}{{range .BuiltinFlags}}	{{row $.FlagWidth .Names .Description}}
+{{end}}{{range .FlagGroups}}
+{{upper .Name}}:
+{{range .Flags}}	{{row $.FlagWidth .Names .Description}}
+{{end}}{{end}}{{if .HasSubcommands}}{{if .Subcommands}}
+SUBCOMMANDS:
+{{range .Subcommands}}	{{row $.SubcommandWidth .Names .Description}}
+{{end}}{{end}}{{range .SubcommandGroups}}
+{{upper .Name}}:
+{{range .Subcommands}}	{{row $.SubcommandWidth .Names .Description}}
+{{end}}{{end}}{{end}}{{if and .Long .Examples}}
+EXAMPLES:
+{{range .Examples}}	{{.Command}}
+{{if .Explanation}}		{{wrap 16 .Explanation}}
+{{end}}{{end}}{{end}}`
+
+// HelpData is the data model help templates are executed with.
+type HelpData struct {
+	// Name is the command's full path joined with dashes, and Path is the
+	// same path as a list.
+	Name            string
+	Path            []string
+	Version         string
+	Author          string
+	Description     string
+	LongDescription string
+	// Usage is the command's usage line, such as "root sub [FILE]".
+	Usage string
+	// Long reports whether long help was requested with --help, rather than
+	// short help with -h.
+	Long bool
+	// Width is the width of the terminal help is being written to, falling
+	// back to the COLUMNS environment variable, or 0 if neither is known.
+	Width int
+	// Flags holds the command's own flags without a group, excluding hidden
+	// ones, BuiltinFlags holds the help and version flags, and FlagGroups
+	// holds the rest, in the order their groups first appear. FlagWidth is
+	// the length of the longest names in any of them, for use with pad and
+	// column.
+	Flags        []HelpFlag
+	BuiltinFlags []HelpFlag
+	FlagGroups   []HelpFlagGroup
+	FlagWidth    int
+	// Args holds the command's positional arguments, and ArgWidth is the
+	// length of the longest of their names. HasArgHelp reports whether any
+	// of them have help text.
+	Args       []ArgSpec
+	ArgWidth   int
+	HasArgHelp bool
+	// HasSubcommands reports whether the command has subcommands, even if
+	// none of them are listed. Subcommands holds the listed subcommands
+	// without a group, and SubcommandGroups holds the rest, in the order
+	// their groups first appear. Hidden and deprecated subcommands are
+	// omitted.
+	HasSubcommands   bool
+	Subcommands      []HelpSubcommand
+	SubcommandGroups []HelpSubcommandGroup
+	SubcommandWidth  int
+	Examples         []Example
+}
+
+// HelpFlag is a single flag listed in help, with its names formatted such as
+// "-o, --output=OUTPUT".
+type HelpFlag struct {
+	Names       string
+	Description string
+}
+
+// HelpFlagGroup is a group of flags listed under a heading.
+type HelpFlagGroup struct {
+	Name  string
+	Flags []HelpFlag
+}
+
+// HelpSubcommand is a single subcommand listed in help, with its name and
+// aliases formatted such as "remove, rm".
+type HelpSubcommand struct {
+	Names       string
+	Description string
+}
+
+// HelpSubcommandGroup is a group of subcommands listed under a heading.
+type HelpSubcommandGroup struct {
+	Name        string
+	Subcommands []HelpSubcommand
+}
+
+// Example is an example invocation of a command.
+type Example struct {
+	Command     string
+	Explanation string
+}
+
+// PrintHelp writes the long help for c to stderr.
+func (c Cmd) PrintHelp(parentNames []string) {
+	c.WriteHelp(os.Stderr, parentNames, true)
+}
+
+// WriteHelp renders c's HelpTemplate, or DefaultHelpTemplate if it is empty,
+// to w. The template has access to the following functions in addition to
+// the text/template builtins:
+//
+//	pad n s     s padded with spaces to one more than n characters
+//	column n    the column text after pad n starts at, accounting for the
+//	            leading tab
+//	wrap col s  s wrapped to the terminal width, with continuation lines
+//	            indented to col
+//	row n s d   s padded as with pad n followed by d wrapped at column n, or
+//	            just s if d is empty
+//	upper s     s in upper case
+//	join sep s  the elements of s joined with sep
+func (c Cmd) WriteHelp(w io.Writer, parentNames []string, long bool) error {
+	text := c.HelpTemplate
+	if text == "" {
+		text = DefaultHelpTemplate
+	}
+
+	data := c.helpData(parentNames, long, terminalWidth(w))
+
+	pad := func(n int, s string) string {
+		return s + strings.Repeat(" ", 1+n-len(s))
+	}
+	column := func(n int) int { return 8 + n + 1 }
+
+	t, err := template.New("help").Funcs(template.FuncMap{
+		"pad":    pad,
+		"column": column,
+		"wrap": func(col int, s string) string {
+			return wrap(s, col, data.Width)
+		},
+		"row": func(n int, s, d string) string {
+			if d == "" {
+				return s
+			}
+
+			return pad(n, s) + wrap(d, column(n), data.Width)
+		},
+		"upper": strings.ToUpper,
+		"join": func(sep string, s []string) string {
+			return strings.Join(s, sep)
+		},
+	}).Parse(text)
+	if err != nil {
+		return err
+	}
+
+	return t.Execute(w, data)
+}
+
+func (c Cmd) helpData(parentNames []string, long bool, width int) HelpData {
+	path := childNames(parentNames, c.Name)
+
+	data := HelpData{
+		Name:            strings.Join(path, "-"),
+		Path:            path,
+		Version:         c.Version,
+		Author:          c.Author,
+		Description:     c.Description,
+		LongDescription: c.LongDescription,
+		Usage:           c.usage(parentNames),
+		Long:            long,
+		Width:           width,
+		Args:            c.ArgSpecs(),
+		HasSubcommands:  c.Subcommands != nil,
+		Examples:        c.Examples,
+	}
+
+	for _, flag := range c.FlagSpecs() {
+		if flag.Hidden {
+			continue
+		}
+
+		names := strings.Join(append(flag.ShortNames(), flag.LongNames()...), ", ")
+		if flag.OptionalVal {
+			names += "[=" + flag.Placeholder + "]"
+		} else if flag.TakesVal {
+			names += "=" + flag.Placeholder
+		}
+		item := HelpFlag{Names: names, Description: flag.Help}
+		data.FlagWidth = max(data.FlagWidth, len(item.Names))
+
+		if flag.Group == "" {
+			data.Flags = append(data.Flags, item)
+			continue
+		}
+
+		found := false
+		for i := range data.FlagGroups {
+			if data.FlagGroups[i].Name == flag.Group {
+				data.FlagGroups[i].Flags = append(data.FlagGroups[i].Flags, item)
+				found = true
+				break
+			}
+		}
+		if !found {
+			data.FlagGroups = append(data.FlagGroups, HelpFlagGroup{
+				Name: flag.Group, Flags: []HelpFlag{item}})
+		}
+	}
+
+	data.BuiltinFlags = []HelpFlag{{Names: "-h, --help",
+		Description: "Prints help information"}}
+	if c.Version != "" {
+		data.BuiltinFlags = append(data.BuiltinFlags, HelpFlag{
+			Names: "-v, --version", Description: "Prints version information"})
+	}
+
+	for _, subcommand := range c.Subcommands {
+		if !subcommand.listed() {
+			continue
+		}
+
+		item := HelpSubcommand{
+			Names: strings.Join(append([]string{subcommand.Name},
+				subcommand.Aliases...), ", "),
+			Description: subcommand.Description,
+		}
+		data.SubcommandWidth = max(data.SubcommandWidth, len(item.Names))
+
+		if subcommand.Group == "" {
+			data.Subcommands = append(data.Subcommands, item)
+			continue
+		}
+
+		found := false
+		for i := range data.SubcommandGroups {
+			if data.SubcommandGroups[i].Name == subcommand.Group {
+				data.SubcommandGroups[i].Subcommands = append(
+					data.SubcommandGroups[i].Subcommands, item)
+				found = true
+				break
+			}
+		}
+		if !found {
+			data.SubcommandGroups = append(data.SubcommandGroups, HelpSubcommandGroup{
+				Name: subcommand.Group, Subcommands: []HelpSubcommand{item}})
+		}
+	}
+
+	for _, flag := range data.BuiltinFlags {
+		data.FlagWidth = max(data.FlagWidth, len(flag.Names))
+	}
+
+	for _, arg := range data.Args {
+		data.ArgWidth = max(data.ArgWidth, len(arg.Name))
+		data.HasArgHelp = data.HasArgHelp || arg.Help != ""
+	}
+
+	return data
+}
+
+// listed reports whether c should be listed in its parent's help.
+func (c Cmd) listed() bool {
+	return !c.Hidden && c.Deprecated == ""
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+// terminalWidth returns the width of w if it is a terminal, falling back to
+// the COLUMNS environment variable, or 0 if neither is available.
+func terminalWidth(w io.Writer) int {
+	if f, ok := w.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		width, _, err := term.GetSize(int(f.Fd()))
+		if err == nil {
+			return width
+		}
+	}
+
+	width, err := strconv.Atoi(os.Getenv("COLUMNS"))
+	if err == nil {
+		return width
+	}
+
+	return 0
+}
+
+// minWrapWidth is the narrowest text will be wrapped to, below which it is
+// left as is rather than producing a column of single words.
+const minWrapWidth = 20
+
+// wrap wraps s, which starts at column col, to width. Continuation lines are
+// indented to col, with a tab for the first 8 columns.
+func wrap(s string, col int, width int) string {
+	if width-col < minWrapWidth {
+		return s
+	}
+
+	indent := strings.Repeat(" ", col)
+	if col >= 8 {
+		indent = "\t" + strings.Repeat(" ", col-8)
+	}
+
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && col+len(line)+1+len(word) > width {
+				lines = append(lines, line)
+				line = word
+			} else if line == "" {
+				line = word
+			} else {
+				line += " " + word
+			}
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n"+indent)
+}
@@ -0,0 +1,230 @@
+package gah
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var helpCmd = Cmd{
+	Name:        "root",
+	Version:     "v1.0.0",
+	Author:      "Test Author",
+	Description: "Does root things.",
+	Subcommands: []Cmd{
+		{
+			Name:        "sub",
+			Aliases:     []string{"s"},
+			Description: "Does sub things.",
+			Function: func(_ struct{}, a struct {
+				Files []string `min:"1" help:"Files to sub."`
+			}) {
+			},
+			Examples: []Example{
+				{Command: "root sub a b", Explanation: "Subs a and b."},
+			},
+		},
+		{
+			Name:        "other",
+			Description: "Does other things.",
+		},
+	},
+}
+
+func TestWriteHelp(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, helpCmd.WriteHelp(&buf, nil, true))
+	assert.Equal(t, `root v1.0.0
+Test Author
+Does root things.
+
+USAGE:
+	root [SUBCOMMAND]
+
+FLAGS:
+	-h, --help    Prints help information
+	-v, --version Prints version information
+
+SUBCOMMANDS:
+	sub, s Does sub things.
+	other  Does other things.
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, helpCmd.Subcommands[0].WriteHelp(&buf, []string{"root"}, false))
+	assert.Equal(t, `root-sub
+Does sub things.
+
+USAGE:
+	root sub ...FILES
+
+ARGS:
+	FILES Files to sub.
+
+FLAGS:
+	-h, --help Prints help information
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, helpCmd.Subcommands[0].WriteHelp(&buf, []string{"root"}, true))
+	assert.Contains(t, buf.String(), "\nEXAMPLES:\n\troot sub a b\n\t\tSubs a and b.\n")
+}
+
+func TestHelpTemplate(t *testing.T) {
+	cmd := Cmd{
+		Name:         "root",
+		HelpTemplate: `{{join " " .Path}}{{if .Long}} (long){{end}}: {{upper .Usage}}`,
+		Function:     func(_ struct{}, _ struct{ Arg string }) {},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, cmd.WriteHelp(&buf, nil, false))
+	assert.Equal(t, "root: ROOT ARG", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, cmd.WriteHelp(&buf, nil, true))
+	assert.Equal(t, "root (long): ROOT ARG", buf.String())
+
+	res, err := cmd.Parse([]string{"", "-h"}, nil)
+	assert.NoError(t, err)
+	assert.True(t, res.Help)
+	assert.False(t, res.LongHelp)
+	res, err = cmd.Parse([]string{"", "--help"}, nil)
+	assert.NoError(t, err)
+	assert.True(t, res.LongHelp)
+}
+
+func TestArgNames(t *testing.T) {
+	cmd := Cmd{
+		Name: "cp",
+		Function: func(_ struct {
+			Mode string `placeholder:"MODE" hint:"file"`
+		}, _ struct {
+			Sources []int  `name:"SRC" placeholder:"<src>" help:"Files to copy." hint:"file"`
+			Dest    string `name:"DEST" help:"Where to copy to." hint:"dir"`
+		}) {
+		},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, cmd.WriteHelp(&buf, nil, true))
+	assert.Contains(t, buf.String(), "\tcp [...<src>] DEST\n")
+	assert.Contains(t, buf.String(), "\nARGS:\n\tSRC  Files to copy.\n\tDEST Where to copy to.\n")
+	assert.Contains(t, buf.String(), "-m, --mode=MODE")
+
+	assert.EqualError(t, cmd.Eval([]string{""}, nil), "expected value for argument DEST")
+	assert.EqualError(t, cmd.Eval([]string{"", "a", "b"}, nil),
+		"error unmarshalling argument for SRC a: strconv.ParseInt: parsing \"a\": invalid syntax")
+
+	args := cmd.ArgSpecs()
+	assert.Equal(t, "<src>", args[0].Placeholder)
+	assert.Equal(t, "file", args[0].Hint)
+	assert.Equal(t, "dir", args[1].Hint)
+	assert.Equal(t, "file", cmd.FlagSpecs()[0].Hint)
+}
+
+func TestHelpWithoutArgHelp(t *testing.T) {
+	cmd := Cmd{
+		Name: "cat",
+		Function: func(_ struct {
+			Verbose bool
+		}, _ struct {
+			Files []string
+		}) {
+		},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, cmd.WriteHelp(&buf, nil, true))
+	assert.Equal(t, `cat
+
+USAGE:
+	cat [...FILES]
+
+FLAGS:
+	-v, --verbose
+	-h, --help    Prints help information
+`, buf.String())
+}
+
+func TestWrap(t *testing.T) {
+	assert.Equal(t, "one two three", wrap("one two three", 0, 0))
+	assert.Equal(t, "aaaa bbbb cccc dddd\neeee ffff", wrap("aaaa bbbb cccc dddd eeee ffff", 0, 20))
+	assert.Equal(t, "aaaa bbbb cccc dddd\n\t  eeee", wrap("aaaa bbbb cccc dddd eeee", 10, 30))
+}
+
+func TestSubcommandGroups(t *testing.T) {
+	called := false
+
+	cmd := Cmd{
+		Name:            "root",
+		LongDescription: "Does root things at length.",
+		Subcommands: []Cmd{
+			{Name: "run", Description: "Runs things."},
+			{Name: "image", Description: "Manages images.", Group: "Management Commands"},
+			{Name: "volume", Description: "Manages volumes.", Group: "Management Commands"},
+			{Name: "hash-object", Description: "Hashes objects.", Group: "Plumbing"},
+			{Name: "secret", Hidden: true},
+			{
+				Name:       "old",
+				Deprecated: "use run instead",
+				Function: func(_ struct{}, _ struct{}) {
+					called = true
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, cmd.WriteHelp(&buf, nil, true))
+	assert.Equal(t, `root
+
+Does root things at length.
+
+USAGE:
+	root [SUBCOMMAND]
+
+FLAGS:
+	-h, --help Prints help information
+
+SUBCOMMANDS:
+	run         Runs things.
+
+MANAGEMENT COMMANDS:
+	image       Manages images.
+	volume      Manages volumes.
+
+PLUMBING:
+	hash-object Hashes objects.
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, cmd.WriteHelp(&buf, nil, false))
+	assert.NotContains(t, buf.String(), "at length")
+
+	res, err := cmd.Parse([]string{"", "old"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"subcommand old is deprecated: use run instead"}, res.Warnings)
+	assert.NoError(t, res.Run())
+	assert.True(t, called)
+
+	_, err = cmd.Parse([]string{"", "secret"}, nil)
+	assert.NoError(t, err)
+}
+
+func TestHelpSubcommand(t *testing.T) {
+	var stderr, expected bytes.Buffer
+	cmd := helpCmd
+	cmd.Stderr = &stderr
+
+	assert.NoError(t, cmd.Eval([]string{"", "help", "s"}, nil))
+	assert.NoError(t, cmd.Subcommands[0].WriteHelp(&expected, []string{"root"}, true))
+	assert.Equal(t, expected.String(), stderr.String())
+
+	stderr.Reset()
+	expected.Reset()
+	assert.NoError(t, cmd.Eval([]string{"", "help"}, nil))
+	assert.NoError(t, cmd.WriteHelp(&expected, nil, true))
+	assert.Equal(t, expected.String(), stderr.String())
+}
@@ -0,0 +1,73 @@
+// Package fields contains the parts of reading flags and args struct fields
+// that are shared by gah and its analyzer, so that the two can't disagree.
+package fields
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TagPairs splits tag into its keys and values, following the conventional
+// format described by reflect.StructTag.
+func TagPairs(tag reflect.StructTag) [][2]string {
+	var pairs [][2]string
+
+	s := string(tag)
+	for s != "" {
+		s = strings.TrimLeft(s, " ")
+
+		i := strings.Index(s, `:"`)
+		if i <= 0 {
+			break
+		}
+		key := s[:i]
+		s = s[i+1:]
+
+		// find the closing quote, skipping escaped characters
+		j := 1
+		for j < len(s) && s[j] != '"' {
+			if s[j] == '\\' {
+				j++
+			}
+			j++
+		}
+		if j >= len(s) {
+			break
+		}
+
+		value, err := strconv.Unquote(s[:j+1])
+		if err != nil {
+			break
+		}
+		pairs = append(pairs, [2]string{key, value})
+		s = s[j+1:]
+	}
+
+	return pairs
+}
+
+// PascalToKebab converts a PascalCase field name, such as DryRun, to the
+// kebab-case used on the command line, such as dry-run.
+func PascalToKebab(s string) string {
+	if len(s) == 0 {
+		return ""
+	}
+
+	runes := []rune(s)
+	res := []rune{unicode.ToLower(runes[0])}
+	runes = runes[1:]
+
+	for _, r := range runes {
+		if unicode.IsUpper(r) {
+			res = append(res, '-', unicode.ToLower(r))
+		} else if unicode.IsDigit(r) {
+			res = append(res, '-', r)
+		} else {
+			res = append(res, r)
+		}
+	}
+
+	return string(res)
+}
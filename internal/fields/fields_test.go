@@ -0,0 +1,22 @@
+package fields
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagPairs(t *testing.T) {
+	assert.Equal(t, [][2]string{{"short", "a"}, {"help", `say "hi"`}},
+		TagPairs(reflect.StructTag(`short:"a"  help:"say \"hi\""`)))
+	assert.Equal(t, [][2]string{{"short", "a"}},
+		TagPairs(reflect.StructTag(`short:"a" help:"unterminated`)))
+	assert.Empty(t, TagPairs(reflect.StructTag(`novalue`)))
+}
+
+func TestPascalToKebab(t *testing.T) {
+	assert.Equal(t, "", PascalToKebab(""))
+	assert.Equal(t, "dry-run", PascalToKebab("DryRun"))
+	assert.Equal(t, "ipv-4", PascalToKebab("Ipv4"))
+}
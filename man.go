@@ -0,0 +1,188 @@
+package gah
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GenerateManPage writes a roff formatted man page for c to w.
+func (c Cmd) GenerateManPage(w io.Writer, section int) error {
+	return c.generateManPage(w, section, []string{})
+}
+
+// GenerateManPages writes a man page for c and each of its subcommands to dir,
+// named the same way as the headers printed by PrintHelp, such as
+// "root-sub.1". Subcommands without their own Author or Version inherit their
+// parent's.
+func (c Cmd) GenerateManPages(dir string, section int) error {
+	return c.generateManPages(dir, section, []string{})
+}
+
+func (c Cmd) generateManPages(dir string, section int, parentNames []string) error {
+	name := strings.Join(childNames(parentNames, c.Name), "-")
+	f, err := os.Create(filepath.Join(dir, fmt.Sprintf("%s.%d", name, section)))
+	if err != nil {
+		return err
+	}
+
+	err = c.generateManPage(f, section, parentNames)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, subcommand := range c.Subcommands {
+		if subcommand.Hidden {
+			continue
+		}
+
+		if subcommand.Author == "" {
+			subcommand.Author = c.Author
+		}
+		if subcommand.Version == "" {
+			subcommand.Version = c.Version
+		}
+
+		err = subcommand.generateManPages(dir, section,
+			childNames(parentNames, c.Name))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c Cmd) generateManPage(w io.Writer, section int, parentNames []string) error {
+	names := childNames(parentNames, c.Name)
+	title := strings.Join(names, "-")
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, ".TH \"%s\" \"%d\" \"\" \"%s\"\n", roffEscape(strings.ToUpper(title)),
+		section, roffEscape(strings.TrimSpace(title+" "+c.Version)))
+
+	b.WriteString(".SH NAME\n")
+	if c.Description == "" {
+		b.WriteString(roffEscape(title) + "\n")
+	} else {
+		b.WriteString(roffEscape(title) + " \\- " + roffEscape(c.Description) + "\n")
+	}
+
+	b.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(&b, "\\fB%s\\fR", roffEscape(strings.Join(names, " ")))
+	usage := strings.TrimPrefix(c.usage(parentNames), strings.Join(names, " "))
+	b.WriteString(" [\\fIOPTIONS\\fR]" + roffEscape(usage) + "\n")
+
+	if c.Description != "" || c.LongDescription != "" {
+		b.WriteString(".SH DESCRIPTION\n")
+		if c.Description != "" {
+			b.WriteString(roffText(c.Description) + "\n")
+		}
+		if c.Description != "" && c.LongDescription != "" {
+			b.WriteString(".PP\n")
+		}
+		if c.LongDescription != "" {
+			b.WriteString(roffText(c.LongDescription) + "\n")
+		}
+	}
+
+	if args := c.ArgSpecs(); len(args) > 0 {
+		b.WriteString(".SH ARGUMENTS\n")
+		for _, arg := range args {
+			b.WriteString(".TP\n\\fI" + roffEscape(arg.Name) + "\\fR\n")
+			if arg.Help != "" {
+				b.WriteString(roffText(arg.Help) + "\n")
+			}
+		}
+	}
+
+	b.WriteString(".SH OPTIONS\n")
+	for _, flag := range c.FlagSpecs() {
+		if flag.Hidden {
+			continue
+		}
+
+		var names []string
+		for _, name := range append(flag.ShortNames(), flag.LongNames()...) {
+			names = append(names, "\\fB"+roffEscape(name)+"\\fR")
+		}
+		b.WriteString(".TP\n" + strings.Join(names, ", "))
+		if flag.OptionalVal {
+			fmt.Fprintf(&b, "[=\\fI%s\\fR]", roffEscape(flag.Placeholder))
+		} else if flag.TakesVal {
+			fmt.Fprintf(&b, " \\fI%s\\fR", roffEscape(flag.Placeholder))
+		}
+		b.WriteString("\n")
+		if flag.Help != "" {
+			b.WriteString(roffText(flag.Help) + "\n")
+		}
+		if flag.Deprecated != "" {
+			b.WriteString("Deprecated: " + roffText(flag.Deprecated) + "\n")
+		}
+	}
+	b.WriteString(".TP\n\\fB\\-h\\fR, \\fB\\-\\-help\\fR\nPrints help information\n")
+	if c.Version != "" {
+		b.WriteString(".TP\n\\fB\\-v\\fR, \\fB\\-\\-version\\fR\nPrints version information\n")
+	}
+
+	if c.Subcommands != nil {
+		b.WriteString(".SH COMMANDS\n")
+		for _, subcommand := range c.Subcommands {
+			if subcommand.Hidden {
+				continue
+			}
+
+			subcommandNames := make([]string, 0, len(subcommand.Aliases)+1)
+			for _, name := range append([]string{subcommand.Name}, subcommand.Aliases...) {
+				subcommandNames = append(subcommandNames, "\\fB"+roffEscape(name)+"\\fR")
+			}
+			b.WriteString(".TP\n" + strings.Join(subcommandNames, ", ") + "\n")
+			if subcommand.Description != "" {
+				b.WriteString(roffText(subcommand.Description) + "\n")
+			}
+			if subcommand.Deprecated != "" {
+				b.WriteString("Deprecated: " + roffText(subcommand.Deprecated) + "\n")
+			}
+			fmt.Fprintf(&b, "See \\fB%s\\fR(%d).\n",
+				roffEscape(title+"-"+subcommand.Name), section)
+		}
+	}
+
+	if c.Author != "" {
+		b.WriteString(".SH AUTHOR\n" + roffText(c.Author) + "\n")
+	}
+
+	if c.Version != "" {
+		b.WriteString(".SH VERSION\n" + roffText(c.Version) + "\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// roffEscape escapes the characters in s that roff would otherwise interpret.
+func roffEscape(s string) string {
+	return strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+}
+
+// roffText escapes s for use as a block of text, additionally preventing
+// lines beginning with control characters from being treated as requests.
+func roffText(s string) string {
+	lines := strings.Split(roffEscape(s), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
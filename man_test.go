@@ -0,0 +1,74 @@
+package gah
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var manCmd = Cmd{
+	Name:        "root",
+	Author:      "Test Author",
+	Version:     "v1.0.0",
+	Description: "Does root things.",
+	Subcommands: []Cmd{
+		{
+			Name:        "sub",
+			Aliases:     []string{"s"},
+			Description: "Does sub things.",
+			Function: func(f struct {
+				DryRun bool   `help:"Don't do anything."`
+				Output string `short:"o"`
+			}, a struct {
+				Files []string `min:"1"`
+			}) {
+			},
+		},
+	},
+}
+
+func TestGenerateManPage(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, manCmd.GenerateManPage(&buf, 1))
+	assert.Equal(t, `.TH "ROOT" "1" "" "root v1.0.0"
+.SH NAME
+root \- Does root things.
+.SH SYNOPSIS
+\fBroot\fR [\fIOPTIONS\fR] [SUBCOMMAND]
+.SH DESCRIPTION
+Does root things.
+.SH OPTIONS
+.TP
+\fB\-h\fR, \fB\-\-help\fR
+Prints help information
+.TP
+\fB\-v\fR, \fB\-\-version\fR
+Prints version information
+.SH COMMANDS
+.TP
+\fBsub\fR, \fBs\fR
+Does sub things.
+See \fBroot\-sub\fR(1).
+.SH AUTHOR
+Test Author
+.SH VERSION
+v1.0.0
+`, buf.String())
+}
+
+func TestGenerateManPages(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, manCmd.GenerateManPages(dir, 1))
+
+	assert.FileExists(t, filepath.Join(dir, "root.1"))
+	page, err := os.ReadFile(filepath.Join(dir, "root-sub.1"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(page), `.TH "ROOT\-SUB" "1" "" "root\-sub v1.0.0"`)
+	assert.Contains(t, string(page), `\fBroot sub\fR [\fIOPTIONS\fR] ...FILES`)
+	assert.Contains(t, string(page), ".TP\n\\fB\\-d\\fR, \\fB\\-\\-dry\\-run\\fR\nDon't do anything.\n")
+	assert.Contains(t, string(page), ".TP\n\\fB\\-o\\fR, \\fB\\-\\-output\\fR \\fIOUTPUT\\fR\n")
+	assert.Contains(t, string(page), ".SH AUTHOR\nTest Author\n")
+}
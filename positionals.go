@@ -0,0 +1,140 @@
+package gah
+
+import (
+	"reflect"
+
+	"mtoohey.com/gah/unmarshal"
+)
+
+// positionalMatcher decides how many positional values each argument takes.
+// Variable arguments take as many values as possible, which can be made
+// explicit by tagging them with greedy, or as few as possible if they are
+// tagged with lazy, while still allowing every value to be unmarshalled by the
+// argument it is assigned to. Values that would be read from a file or stdin
+// aren't unmarshalled while matching, since that can't be undone if the
+// assignment is abandoned, so they are assumed to be valid until the chosen
+// assignment is unmarshalled.
+type positionalMatcher struct {
+	values        []string
+	infos         []argInfo
+	unmarshallers []unmarshal.ValueUnmarshaller
+	// minAfter and maxAfter hold the total minimum and maximum number of
+	// values that the arguments after each one can take
+	minAfter []int
+	maxAfter []int
+	counts   []int
+	results  map[[2]int]reflect.Value
+	errs     map[[2]int]error
+	failed   map[[2]int]bool
+	// firstErr is the first unmarshalling error encountered, which is the one
+	// reported if no assignment succeeds
+	firstErr *positionalError
+}
+
+type positionalError struct {
+	info  int
+	value int
+	error error
+}
+
+func newPositionalMatcher(values []string, infos []argInfo,
+	c unmarshal.CustomValueUnmarshallers) *positionalMatcher {
+	m := &positionalMatcher{
+		values:        values,
+		infos:         infos,
+		unmarshallers: make([]unmarshal.ValueUnmarshaller, len(infos)),
+		minAfter:      make([]int, len(infos)),
+		maxAfter:      make([]int, len(infos)),
+		counts:        make([]int, len(infos)),
+		results:       map[[2]int]reflect.Value{},
+		errs:          map[[2]int]error{},
+		failed:        map[[2]int]bool{},
+	}
+
+	for k := len(infos) - 1; k >= 0; k-- {
+		m.unmarshallers[k] = infos[k].Unmarshaller(c)
+		if k < len(infos)-1 {
+			m.minAfter[k] = m.minAfter[k+1] + infos[k+1].Min()
+			m.maxAfter[k] = addMax(m.maxAfter[k+1], infos[k+1].Max())
+		}
+	}
+
+	return m
+}
+
+// unmarshal unmarshals the value at index i for the argument at index k,
+// remembering the result so that it is only done once.
+func (m *positionalMatcher) unmarshal(k, i int) (reflect.Value, error) {
+	key := [2]int{k, i}
+	if err, ok := m.errs[key]; ok {
+		return reflect.Value{}, err
+	} else if res, ok := m.results[key]; ok {
+		return res, nil
+	}
+
+	res, err := m.unmarshallers[k](m.values[i], m.infos[k].Field().Tag)
+	if err != nil {
+		m.errs[key] = err
+		if m.firstErr == nil {
+			m.firstErr = &positionalError{info: k, value: i, error: err}
+		}
+		return reflect.Value{}, err
+	}
+
+	m.results[key] = res
+	return res, nil
+}
+
+// match reports whether the values from index i onwards can be assigned to
+// the arguments from index k onwards, recording the number of values each
+// argument takes in counts if so.
+func (m *positionalMatcher) match(k, i int) bool {
+	if k == len(m.infos) {
+		return i == len(m.values)
+	}
+
+	key := [2]int{k, i}
+	if m.failed[key] {
+		return false
+	}
+
+	remaining := len(m.values) - i
+	lo := max(m.infos[k].Min(), remaining-m.maxAfter[k])
+	hi := remaining - m.minAfter[k]
+	if m.infos[k].Max() < hi {
+		hi = m.infos[k].Max()
+	}
+
+	_, lazy := m.infos[k].Field().Tag.Lookup("lazy")
+	for n := lo; n <= hi; n++ {
+		count := n
+		if !lazy {
+			count = hi - (n - lo)
+		}
+
+		if m.unmarshalsAll(k, i, count) && m.match(k+1, i+count) {
+			m.counts[k] = count
+			return true
+		}
+	}
+
+	m.failed[key] = true
+	return false
+}
+
+// unmarshalsAll reports whether the count values from index i can be
+// unmarshalled by the argument at index k, skipping any that would be read
+// from a source.
+func (m *positionalMatcher) unmarshalsAll(k, i, count int) bool {
+	for j := i; j < i+count; j++ {
+		if unmarshal.ReadsSource(m.values[j], m.infos[k].Field().Tag) {
+			continue
+		}
+
+		if _, err := m.unmarshal(k, j); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
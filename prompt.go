@@ -0,0 +1,134 @@
+package gah
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"golang.org/x/term"
+	"mtoohey.com/gah/unmarshal"
+)
+
+// Prompter asks the user for the values of flags and arguments tagged with
+// prompt that were not provided on the command line.
+type Prompter interface {
+	// Interactive reports whether the user can be prompted. When it is false,
+	// missing values are reported as errors instead.
+	Interactive() bool
+	// Prompt asks the user for a value, displaying message, without echoing
+	// the input if secret is true.
+	Prompt(message string, secret bool) (string, error)
+}
+
+// TerminalPrompter prompts on a terminal, reading from In and writing prompts
+// to Out. It is interactive only when In is a terminal.
+type TerminalPrompter struct {
+	In  *os.File
+	Out io.Writer
+}
+
+func (p *TerminalPrompter) Interactive() bool {
+	return term.IsTerminal(int(p.In.Fd()))
+}
+
+func (p *TerminalPrompter) Prompt(message string, secret bool) (string, error) {
+	fmt.Fprintf(p.Out, "%s: ", message)
+
+	if secret {
+		b, err := term.ReadPassword(int(p.In.Fd()))
+		fmt.Fprintln(p.Out)
+		return string(b), err
+	}
+
+	// the input is read a byte at a time so that nothing following the line
+	// is consumed
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := p.In.Read(b)
+		if n == 1 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+
+		if errors.Is(err, io.EOF) && len(line) > 0 {
+			break
+		} else if err != nil {
+			return "", err
+		}
+	}
+
+	return strings.TrimSuffix(string(line), "\r"), nil
+}
+
+// prompter returns the Prompter used by c, defaulting to a TerminalPrompter
+// on stdin and c's stderr.
+func (c Cmd) prompter() Prompter {
+	if c.Prompter == nil {
+		return &TerminalPrompter{In: os.Stdin, Out: c.stderr()}
+	}
+
+	return c.Prompter
+}
+
+// promptValue prompts with the given message until a value is entered that
+// unmarshaller accepts, reporting any errors to w.
+func promptValue(p Prompter, w io.Writer, message string, tag reflect.StructTag,
+	unmarshaller func(string, reflect.StructTag) (reflect.Value, error),
+) (string, reflect.Value, error) {
+	_, secret := tag.Lookup("secret")
+
+	for {
+		s, err := p.Prompt(message, secret)
+		if err != nil {
+			return "", reflect.Value{}, err
+		}
+
+		v, err := unmarshaller(s, tag)
+		if err == nil {
+			return s, v, nil
+		}
+
+		fmt.Fprintln(w, err)
+	}
+}
+
+// promptFlags prompts for the flags in the chain that were not provided, have
+// no default, and are tagged with prompt, failing if any flags tagged with
+// required remain missing. Flags tagged with prompt are also required when
+// the prompter isn't interactive.
+func (r *ParseResult) promptFlags() error {
+	for _, parsed := range r.Chain {
+		for _, flag := range getFlags(parsed.Flags.Type(), parsed.Cmd.ShortFlags) {
+			value := parsed.Flags.FieldByIndex(flag.field.Index)
+			if parsed.Set[flag.field.Name] || !value.IsZero() {
+				continue
+			}
+
+			message, prompt := flag.field.Tag.Lookup("prompt")
+			prompt = prompt && unmarshal.TakesValue(flag.field)
+			if prompt && r.prompter.Interactive() {
+				_, v, err := promptValue(r.prompter, r.Chain[0].Cmd.stderr(), message,
+					flag.field.Tag, unmarshal.GetValueUnmarshaller(flag.field.Type,
+						parsed.Cmd.CustomValueUnmarshallers))
+				if err != nil {
+					return err
+				}
+
+				value.Set(v)
+				continue
+			}
+
+			if _, required := flag.field.Tag.Lookup("required"); required || prompt {
+				return &ErrMissingRequiredFlag{flag: "--" + flag.Long()}
+			}
+		}
+	}
+
+	return nil
+}
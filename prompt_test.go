@@ -0,0 +1,83 @@
+package gah
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPrompter struct {
+	interactive bool
+	answers     []string
+	messages    []string
+	secret      []bool
+}
+
+func (p *testPrompter) Interactive() bool { return p.interactive }
+
+func (p *testPrompter) Prompt(message string, secret bool) (string, error) {
+	p.messages = append(p.messages, message)
+	p.secret = append(p.secret, secret)
+	answer := p.answers[0]
+	p.answers = p.answers[1:]
+	return answer, nil
+}
+
+func TestPrompt(t *testing.T) {
+	var token string
+	var retries int
+	var name string
+
+	function := func(f struct {
+		Token   string `prompt:"Enter API token" secret:""`
+		Retries int    `prompt:"Retries" minVal:"1"`
+	}, a struct {
+		Name string `prompt:"Name"`
+	}) {
+		token = f.Token
+		retries = f.Retries
+		name = a.Name
+	}
+
+	var stderr bytes.Buffer
+	p := &testPrompter{interactive: true, answers: []string{"alice", "hunter2", "0", "3"}}
+	cmd := Cmd{Function: function, Prompter: p, Stderr: &stderr}
+	assert.NoError(t, cmd.Eval([]string{""}, nil))
+	assert.Equal(t, "hunter2", token)
+	assert.Equal(t, 3, retries)
+	assert.Equal(t, "alice", name)
+	assert.Equal(t, []string{"Name", "Enter API token", "Retries", "Retries"}, p.messages)
+	assert.Equal(t, []bool{false, true, false, false}, p.secret)
+	assert.NotEmpty(t, stderr.String())
+
+	p = &testPrompter{interactive: true}
+	cmd.Prompter = p
+	assert.NoError(t, cmd.Eval([]string{"", "-t", "abc", "-r", "2", "bob"}, nil))
+	assert.Empty(t, p.messages)
+
+	cmd.Prompter = &testPrompter{}
+	assert.ErrorIs(t, cmd.Eval([]string{""}, nil), &ErrExpectedArgumentValue{})
+	err := cmd.Eval([]string{"", "bob"}, nil)
+	assert.ErrorIs(t, err, &ErrMissingRequiredFlag{})
+	assert.Equal(t, "--token", err.(*ErrMissingRequiredFlag).Flag())
+	assert.NoError(t, cmd.Eval([]string{"", "-t", "abc", "-r", "2", "bob"}, nil))
+	assert.Equal(t, "abc", token)
+}
+
+func TestRequiredFlags(t *testing.T) {
+	cmd := Cmd{
+		Function: func(f struct {
+			Output string `required:""`
+		}, _ struct{}) {
+		},
+		Prompter: &testPrompter{},
+		Stderr:   &bytes.Buffer{},
+	}
+
+	err := cmd.Eval([]string{""}, nil)
+	assert.ErrorIs(t, err, &ErrMissingRequiredFlag{})
+	assert.Equal(t, "--output", err.(*ErrMissingRequiredFlag).Flag())
+	assert.NoError(t, cmd.Eval([]string{"", "--output", "out"}, nil))
+	assert.NoError(t, cmd.Eval([]string{"", "--help"}, nil))
+}
@@ -0,0 +1,293 @@
+package gah
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"mtoohey.com/gah/unmarshal"
+)
+
+// maxResponseFileDepth is the deepest response files may reference each
+// other, which also catches files that reference themselves.
+const maxResponseFileDepth = 16
+
+// Location is the position in a response file that an argument was read from.
+type Location struct {
+	File string
+	Line int
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+// responseFileExpander accumulates the result of expanding response files,
+// along with the location each argument came from, which is nil for
+// arguments given directly on the command line. It follows the command each
+// argument is given to, and that command's flags, so that it can tell which
+// arguments are flag values.
+type responseFileExpander struct {
+	args      []string
+	locations []*Location
+	stopped   bool
+	cmd       Cmd
+	short     map[rune]*flagInfo
+	long      map[string]*flagInfo
+	// value is the flag that the next argument is the value of, if any
+	value *flagInfo
+	// positionals is set once the remaining arguments can only be positional
+	positionals bool
+}
+
+// expandResponseFiles replaces each argument of the form @path in inputArgs,
+// other than the first, with the arguments read from the file at path, which
+// may in turn reference other response files. Paths are relative to the
+// working directory. Expansion stops at "--".
+//
+// Values of flags tagged with fromFile are not expanded, so that they are
+// read by the flag instead, and arguments of the form @@path are replaced
+// with @path, so that other values beginning with @ can be given. Which
+// arguments are flag values is decided using the flags of the subcommand
+// they are given to, as when they are parsed.
+func (c Cmd) expandResponseFiles(inputArgs []string) ([]string, []*Location, error) {
+	if len(inputArgs) == 0 {
+		return inputArgs, nil, nil
+	}
+
+	e := responseFileExpander{
+		args:      inputArgs[:1:1],
+		locations: []*Location{nil},
+	}
+	e.setCmd(c)
+
+	err := e.expand(inputArgs[1:], make([]*Location, len(inputArgs)-1), 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return e.args, e.locations, nil
+}
+
+func (e *responseFileExpander) expand(args []string, locations []*Location, depth int) error {
+	for i, arg := range args {
+		if e.stopped || !strings.HasPrefix(arg, "@") || len(arg) == 1 || e.fileValue() {
+			e.add(arg, locations[i])
+			continue
+		}
+
+		if strings.HasPrefix(arg, "@@") {
+			e.add(arg[1:], locations[i])
+			continue
+		}
+
+		path := arg[1:]
+		if depth == maxResponseFileDepth {
+			return &ErrReadingResponseFile{path: path,
+				error: errors.New("response files nested too deeply")}
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return &ErrReadingResponseFile{path: path, error: err}
+		}
+
+		tokens, lines, err := splitResponseFile(string(content))
+		if err != nil {
+			return &ErrReadingResponseFile{path: path, error: err}
+		}
+
+		tokenLocations := make([]*Location, len(tokens))
+		for j, line := range lines {
+			tokenLocations[j] = &Location{File: path, Line: line}
+		}
+
+		err = e.expand(tokens, tokenLocations, depth+1)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setCmd makes c the command that the following arguments are given to.
+func (e *responseFileExpander) setCmd(c Cmd) {
+	e.cmd = c
+	e.short, e.long = nil, nil
+	if c.Function != nil {
+		flags := getFlags(reflect.TypeOf(c.Function).In(0), c.ShortFlags)
+		e.short, e.long = getFlagMaps(flags)
+	}
+}
+
+// fileValue reports whether the next argument is the value of a flag tagged
+// with fromFile.
+func (e *responseFileExpander) fileValue() bool {
+	if e.value == nil {
+		return false
+	}
+
+	_, found := e.value.field.Tag.Lookup("fromFile")
+	return found
+}
+
+// add appends arg to the expanded arguments, then works out what the next
+// argument is the same way parse does.
+func (e *responseFileExpander) add(arg string, location *Location) {
+	e.args = append(e.args, arg)
+	e.locations = append(e.locations, location)
+
+	if e.value != nil {
+		e.value = nil
+		return
+	} else if e.stopped || e.positionals {
+		return
+	}
+
+	switch {
+	case arg == "--":
+		e.stopped = true
+	case strings.HasPrefix(arg, "--"):
+		if !strings.Contains(arg, "=") {
+			e.value = separateValue(e.long[arg[2:]])
+		}
+	case strings.HasPrefix(arg, "-") && len(arg) > 1:
+		runes := []rune(arg[1:])
+		for i, r := range runes {
+			flag, found := e.short[r]
+			if !found {
+				return
+			} else if unmarshal.TakesValue(flag.field) {
+				if i == len(runes)-1 {
+					e.value = separateValue(flag)
+				}
+				return
+			}
+		}
+	case e.cmd.Subcommands == nil:
+		e.positionals = e.cmd.ParseMode == ParsePOSIX || e.cmd.ParseMode == ParsePassthrough
+	default:
+		for _, subcommand := range e.cmd.Subcommands {
+			matched := arg == subcommand.Name
+			for _, alias := range subcommand.Aliases {
+				matched = matched || arg == alias
+			}
+
+			if matched {
+				e.setCmd(subcommand)
+				return
+			}
+		}
+	}
+}
+
+// separateValue returns flag if it is followed by its value as a separate
+// argument, and nil otherwise.
+func separateValue(flag *flagInfo) *flagInfo {
+	if flag == nil || !unmarshal.TakesValue(flag.field) {
+		return nil
+	} else if _, ok := unmarshal.OptionalValue(flag.field.Tag); ok {
+		return nil
+	}
+
+	return flag
+}
+
+// splitResponseFile splits s into arguments the way a shell would, returning
+// the line each argument starts on. Arguments are separated by whitespace,
+// single quotes preserve their contents literally, double quotes allow
+// escaping '"' and '\' with a backslash, and a backslash outside quotes
+// escapes the following character. Words beginning with '#' start a comment
+// that continues to the end of the line.
+func splitResponseFile(s string) ([]string, []int, error) {
+	var tokens []string
+	var lines []int
+
+	runes := []rune(s)
+	line := 1
+	inToken := false
+	var token strings.Builder
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if !inToken {
+			if unicode.IsSpace(r) {
+				if r == '\n' {
+					line++
+				}
+				continue
+			}
+
+			if r == '\\' && i+1 < len(runes) && runes[i+1] == '\n' {
+				i++
+				line++
+				continue
+			}
+
+			if r == '#' {
+				for i+1 < len(runes) && runes[i+1] != '\n' {
+					i++
+				}
+				continue
+			}
+
+			inToken = true
+			lines = append(lines, line)
+		}
+
+		switch {
+		case unicode.IsSpace(r):
+			tokens = append(tokens, token.String())
+			token.Reset()
+			inToken = false
+			if r == '\n' {
+				line++
+			}
+		case r == '\'':
+			start := line
+			for i++; i < len(runes) && runes[i] != '\''; i++ {
+				if runes[i] == '\n' {
+					line++
+				}
+				token.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, nil, fmt.Errorf("line %d: unterminated single quote", start)
+			}
+		case r == '"':
+			start := line
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) &&
+					(runes[i+1] == '"' || runes[i+1] == '\\') {
+					i++
+				} else if runes[i] == '\n' {
+					line++
+				}
+				token.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, nil, fmt.Errorf("line %d: unterminated double quote", start)
+			}
+		case r == '\\' && i+1 < len(runes):
+			i++
+			if runes[i] == '\n' {
+				line++
+			} else {
+				token.WriteRune(runes[i])
+			}
+		default:
+			token.WriteRune(r)
+		}
+	}
+
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+
+	return tokens, lines, nil
+}
@@ -0,0 +1,171 @@
+package gah
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitResponseFile(t *testing.T) {
+	tokens, lines, err := splitResponseFile(`# a comment
+--flag value # trailing comment
+'single quoted' "double \"quoted\""
+multi\
+line '' a#b
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--flag", "value", "single quoted", `double "quoted"`,
+		"multiline", "", "a#b"}, tokens)
+	assert.Equal(t, []int{2, 2, 3, 3, 4, 5, 5}, lines)
+
+	_, _, err = splitResponseFile("'unterminated")
+	assert.Error(t, err)
+	_, _, err = splitResponseFile(`"unterminated`)
+	assert.Error(t, err)
+}
+
+func TestResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	outer := filepath.Join(dir, "outer")
+	inner := filepath.Join(dir, "inner")
+	assert.NoError(t, os.WriteFile(outer, []byte("--verbose\n@"+inner+"\n"), 0o644))
+	assert.NoError(t, os.WriteFile(inner, []byte("1\n\n2\n"), 0o644))
+
+	var verbose bool
+	var numbers []int
+
+	cmd := Cmd{
+		Function: func(f struct{ Verbose bool }, a struct{ Numbers []int }) {
+			verbose = f.Verbose
+			numbers = a.Numbers
+		},
+		ResponseFiles: true,
+	}
+
+	assert.NoError(t, cmd.Eval([]string{"", "0", "@" + outer}, nil))
+	assert.True(t, verbose)
+	assert.Equal(t, []int{0, 1, 2}, numbers)
+
+	err := cmd.Eval([]string{"", "--", "@" + outer}, nil)
+	assert.ErrorIs(t, err, &ErrUnmarshallingArgument{})
+	assert.False(t, errors.Is(err, &ErrFromResponseFile{}))
+
+	assert.NoError(t, os.WriteFile(inner, []byte("1\n\n2 three\n"), 0o644))
+	err = cmd.Eval([]string{"", "0", "@" + outer}, nil)
+	assert.ErrorIs(t, err, &ErrUnmarshallingArgument{})
+	var locationErr *ErrFromResponseFile
+	assert.True(t, errors.As(err, &locationErr))
+	assert.Equal(t, Location{File: inner, Line: 3}, locationErr.Location())
+
+	assert.NoError(t, os.WriteFile(inner, []byte("@"+inner), 0o644))
+	assert.ErrorIs(t, cmd.Eval([]string{"", "@" + outer}, nil), &ErrReadingResponseFile{})
+	assert.ErrorIs(t, cmd.Eval([]string{"", "@" + filepath.Join(dir, "missing")}, nil),
+		&ErrReadingResponseFile{})
+
+	cmd.ResponseFiles = false
+	assert.ErrorIs(t, cmd.Eval([]string{"", "@" + outer}, nil), &ErrUnmarshallingArgument{})
+}
+
+func TestResponseFilesWithFileValues(t *testing.T) {
+	dir := t.TempDir()
+	payload := filepath.Join(dir, "payload.json")
+	args := filepath.Join(dir, "args")
+	assert.NoError(t, os.WriteFile(payload, []byte(`{"a":1}`), 0o644))
+	assert.NoError(t, os.WriteFile(args, []byte("--verbose --body @"+payload+"\n"), 0o644))
+
+	var verbose bool
+	var body string
+	var name string
+
+	cmd := Cmd{
+		Function: func(f struct {
+			Verbose bool
+			Body    string `fromFile:""`
+		}, a struct {
+			Name string `fromFile:""`
+		}) {
+			verbose = f.Verbose
+			body = f.Body
+			name = a.Name
+		},
+		ResponseFiles: true,
+	}
+
+	for _, inputArgs := range [][]string{
+		{"", "--body", "@" + payload, "x"},
+		{"", "-vb", "@" + payload, "x"},
+		{"", "--body=@" + payload, "x"},
+		{"", "@" + args, "x"},
+	} {
+		body = ""
+		assert.NoError(t, cmd.Eval(inputArgs, nil))
+		assert.Equal(t, `{"a":1}`, body)
+	}
+	assert.True(t, verbose)
+
+	assert.NoError(t, cmd.Eval([]string{"", "@@" + payload}, nil))
+	assert.Equal(t, `{"a":1}`, name)
+}
+
+func TestResponseFilesFollowCommands(t *testing.T) {
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args")
+	assert.NoError(t, os.WriteFile(args, []byte("x"), 0o644))
+
+	var name string
+	var body string
+	var bold bool
+	var positionals []string
+
+	cmd := Cmd{
+		Subcommands: []Cmd{
+			{
+				Name: "post",
+				Function: func(f struct {
+					Name string
+					Body string `fromFile:""`
+				}, a struct {
+					Rest []string `min:"0"`
+				}) {
+					name, body, positionals = f.Name, f.Body, a.Rest
+				},
+			},
+			{
+				Name:    "print",
+				Aliases: []string{"p"},
+				Function: func(f struct {
+					Bold bool
+				}, a struct {
+					Rest []string `min:"0"`
+				}) {
+					bold, positionals = f.Bold, a.Rest
+				},
+			},
+		},
+		ResponseFiles: true,
+	}
+
+	// the value of --name isn't a flag, so the following argument is expanded
+	assert.NoError(t, cmd.Eval([]string{"", "post", "--name", "--body", "@" + args}, nil))
+	assert.Equal(t, "--body", name)
+	assert.Equal(t, "", body)
+	assert.Equal(t, []string{"x"}, positionals)
+
+	// -b doesn't take a value in print, even though it does in post
+	for _, subcommand := range []string{"print", "p"} {
+		bold, positionals = false, nil
+		assert.NoError(t, cmd.Eval([]string{"", subcommand, "-b", "@" + args}, nil))
+		assert.True(t, bold)
+		assert.Equal(t, []string{"x"}, positionals)
+	}
+
+	// but in post, it's read by the flag
+	assert.NoError(t, cmd.Eval([]string{"", "post", "-nb", "@" + args}, nil))
+	assert.Equal(t, "b", name)
+	assert.Equal(t, "", body)
+	assert.NoError(t, cmd.Eval([]string{"", "post", "-b", "@" + args}, nil))
+	assert.Equal(t, "x", body)
+}
@@ -0,0 +1,25 @@
+package gah
+
+// Option configures a command built by NewCmd for flags of type F. Function
+// literals taking a *Cmd can be used as options to set any of its fields.
+type Option[F any] func(*Cmd)
+
+// NewCmd returns a command named name that invokes fn, so that a Function
+// with the wrong signature is a compile time error rather than one reported
+// by validate or at runtime. The returned command is an ordinary Cmd, and can
+// be used anywhere one built as a literal can.
+func NewCmd[F, A any](name string, fn func(F, A) error, opts ...Option[F]) Cmd {
+	c := Cmd{Name: name, Function: fn}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// WithDefaultFlags returns an option that sets a command's DefaultFlags,
+// which must be of the same type as its flags.
+func WithDefaultFlags[F any](f F) Option[F] {
+	return func(c *Cmd) {
+		c.DefaultFlags = f
+	}
+}
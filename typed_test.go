@@ -0,0 +1,46 @@
+package gah
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCmd(t *testing.T) {
+	type flags struct {
+		Count int
+		Name  string
+	}
+	type args struct {
+		Files []string
+	}
+
+	var gotFlags flags
+	var gotArgs args
+	errFailed := errors.New("failed")
+
+	cmd := NewCmd("app", func(f flags, a args) error {
+		gotFlags = f
+		gotArgs = a
+		if f.Count < 0 {
+			return errFailed
+		}
+		return nil
+	}, WithDefaultFlags(flags{Count: 2, Name: "x"}), func(c *Cmd) {
+		c.Description = "An app."
+	})
+
+	assert.Equal(t, "app", cmd.Name)
+	assert.Equal(t, "An app.", cmd.Description)
+
+	assert.NoError(t, cmd.Eval([]string{"", "--name", "y", "a", "b"}, nil))
+	assert.Equal(t, flags{Count: 2, Name: "y"}, gotFlags)
+	assert.Equal(t, args{Files: []string{"a", "b"}}, gotArgs)
+
+	assert.ErrorIs(t, cmd.Eval([]string{"", "--count=-1"}, nil), errFailed)
+
+	parent := Cmd{Name: "parent", Subcommands: []Cmd{cmd}}
+	assert.NoError(t, parent.Eval([]string{"", "app", "c"}, nil))
+	assert.Equal(t, args{Files: []string{"c"}}, gotArgs)
+}
@@ -0,0 +1,110 @@
+package unmarshal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Stdin is read from for values of "-" given to fields tagged with stdin.
+var Stdin io.Reader = os.Stdin
+
+// withSource wraps u so that values are read from a file or stdin if the
+// field's tags allow it, before being passed to u:
+//
+//	fromFile:""        values beginning with @ are read from the file they name
+//	fromFile:"always"  values are always the name of the file to read
+//	stdin:""           a value of - is read from stdin
+//	maxSize:"n"        values that are read may be at most n bytes
+//	trim:"newline"     trailing newlines are removed from values that are read
+//	trim:"space"       surrounding whitespace is removed from values that are
+//	                   read
+//
+// Values read for []byte fields are used as is, rather than being passed to u.
+func withSource(t reflect.Type, u ValueUnmarshaller) ValueUnmarshaller {
+	return func(s string, g reflect.StructTag) (reflect.Value, error) {
+		content, read, err := readSource(s, g)
+		if err != nil {
+			return reflect.New(t).Elem(), err
+		} else if !read {
+			return u(s, g)
+		}
+
+		if t == reflect.TypeOf([]byte{}) {
+			return reflect.ValueOf(content), nil
+		}
+
+		return u(string(content), g)
+	}
+}
+
+// ReadsSource reports whether unmarshalling s for a field with tag t reads the
+// value from a file or stdin, rather than using s itself.
+func ReadsSource(s string, t reflect.StructTag) bool {
+	_, stdin := t.Lookup("stdin")
+	fromFile, found := t.Lookup("fromFile")
+	return stdin && s == "-" || found && (fromFile == "always" || strings.HasPrefix(s, "@"))
+}
+
+func readSource(s string, t reflect.StructTag) ([]byte, bool, error) {
+	if !ReadsSource(s, t) {
+		return nil, false, nil
+	}
+
+	var r io.Reader
+	var name string
+
+	if _, stdin := t.Lookup("stdin"); stdin && s == "-" {
+		r = Stdin
+		name = "stdin"
+	} else {
+		if t.Get("fromFile") != "always" {
+			s = s[1:]
+		}
+
+		f, err := os.Open(s)
+		if err != nil {
+			return nil, true, err
+		}
+		defer f.Close()
+
+		r = f
+		name = s
+	}
+
+	max := int64(-1)
+	if maxStr, ok := t.Lookup("maxSize"); ok {
+		var err error
+		max, err = strconv.ParseInt(maxStr, 10, 64)
+		if err != nil {
+			panic(err)
+		}
+
+		// one more byte than the maximum is read to detect values that are
+		// too large
+		r = io.LimitReader(r, max+1)
+	}
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, true, err
+	} else if max != -1 && int64(len(content)) > max {
+		return nil, true, fmt.Errorf("%s is larger than maximum size: %d bytes", name, max)
+	}
+
+	return trim(content, t), true, nil
+}
+
+func trim(content []byte, t reflect.StructTag) []byte {
+	switch t.Get("trim") {
+	case "newline":
+		return []byte(strings.TrimRight(string(content), "\r\n"))
+	case "space":
+		return []byte(strings.TrimSpace(string(content)))
+	default:
+		return content
+	}
+}
@@ -0,0 +1,54 @@
+package unmarshal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payload")
+	assert.NoError(t, os.WriteFile(path, []byte("42\n"), 0o644))
+
+	u := GetValueUnmarshaller(reflect.TypeOf(0), nil)
+
+	v, err := u("@"+path, `fromFile:"" trim:"newline"`)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, v.Interface())
+	_, err = u("@"+path, `fromFile:""`)
+	assert.Error(t, err)
+	v, err = u(path, `fromFile:"always" trim:"space"`)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, v.Interface())
+	_, err = u("@"+path, "")
+	assert.Error(t, err)
+	_, err = u("@"+path, `fromFile:"" maxSize:"2"`)
+	assert.Error(t, err)
+	v, err = u("@"+path, `fromFile:"" maxSize:"3" trim:"newline"`)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, v.Interface())
+
+	stdin := Stdin
+	defer func() { Stdin = stdin }()
+	Stdin = strings.NewReader("raw\x00bytes")
+	v, err = GetValueUnmarshaller(reflect.TypeOf([]byte{}), nil)("-", `stdin:""`)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("raw\x00bytes"), v.Interface())
+
+	v, err = GetValueUnmarshaller(reflect.TypeOf(""), nil)("-", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "-", v.Interface())
+}
+
+func TestReadsSource(t *testing.T) {
+	assert.True(t, ReadsSource("-", `stdin:""`))
+	assert.False(t, ReadsSource("-", `fromFile:""`))
+	assert.True(t, ReadsSource("@path", `fromFile:""`))
+	assert.False(t, ReadsSource("path", `fromFile:""`))
+	assert.True(t, ReadsSource("path", `fromFile:"always"`))
+	assert.False(t, ReadsSource("@path", ""))
+}
@@ -14,7 +14,6 @@ import (
 	"time"
 )
 
-// TODO: add support for enums somehow
 // TODO: add tests for all unmarshallers
 // TODO: support all the types that pflag does
 
@@ -42,6 +41,23 @@ func TakesValue(f reflect.StructField) bool {
 	return true
 }
 
+// Choices returns the values permitted by the choices tag, if it is present.
+func Choices(t reflect.StructTag) ([]string, bool) {
+	s, found := t.Lookup("choices")
+	if !found {
+		return nil, false
+	}
+
+	return strings.Split(s, ","), true
+}
+
+// OptionalValue returns the implicit value given by the optionalVal tag, if it
+// is present. Flags with this tag only take a value when it is attached, such
+// as with --color=never, and otherwise use the implicit value.
+func OptionalValue(t reflect.StructTag) (string, bool) {
+	return t.Lookup("optionalVal")
+}
+
 var defaultsToNoNonElementWise = []reflect.Type{reflect.TypeOf(byte(0))}
 
 func ElementWise(f reflect.StructField) bool {
@@ -67,7 +83,15 @@ func ElementWise(f reflect.StructField) bool {
 	return true
 }
 
+// GetValueUnmarshaller returns the unmarshaller for values of type t,
+// preferring those in c. The unmarshaller reads values from files or stdin
+// according to the field's tags, see withSource.
 func GetValueUnmarshaller(t reflect.Type,
+	c CustomValueUnmarshallers) ValueUnmarshaller {
+	return withSource(t, getValueUnmarshaller(t, c))
+}
+
+func getValueUnmarshaller(t reflect.Type,
 	c CustomValueUnmarshallers) ValueUnmarshaller {
 	switch t.Kind() {
 	case reflect.Array:
@@ -79,6 +103,7 @@ func GetValueUnmarshaller(t reflect.Type,
 					t.Elem().Name()))
 			}
 		}
+		u = withChoices(u)
 		return func(s string, g reflect.StructTag) (reflect.Value, error) {
 			subStrs := strings.Split(s, ",")
 			res := reflect.New(reflect.ArrayOf(len(subStrs), t.Elem()))
@@ -101,6 +126,7 @@ func GetValueUnmarshaller(t reflect.Type,
 					t.Elem().Name()))
 			}
 		}
+		u = withChoices(u)
 		return func(s string, g reflect.StructTag) (reflect.Value, error) {
 			subStrs := strings.Split(s, ",")
 			res := reflect.MakeSlice(t, len(subStrs), len(subStrs))
@@ -117,16 +143,37 @@ func GetValueUnmarshaller(t reflect.Type,
 	default:
 		u, found := c[t]
 		if found {
-			return u
+			return withChoices(u)
 		}
 		u, found = valueUnmarshallers[t]
 		if found {
-			return u
+			return withChoices(u)
 		}
 		panic(fmt.Sprintf("no value unmarshaller for type %s", t.Name()))
 	}
 }
 
+// withChoices wraps u so that values must be equal to one of those permitted
+// by the choices tag, if it is present, once both are unmarshalled.
+func withChoices(u ValueUnmarshaller) ValueUnmarshaller {
+	return func(s string, t reflect.StructTag) (reflect.Value, error) {
+		res, err := u(s, t)
+		choices, ok := Choices(t)
+		if err != nil || !ok {
+			return res, err
+		}
+
+		for _, choice := range choices {
+			choiceRes, err := u(choice, t)
+			if err == nil && reflect.DeepEqual(choiceRes.Interface(), res.Interface()) {
+				return res, nil
+			}
+		}
+
+		return res, fmt.Errorf("%s is not one of: %s", s, strings.Join(choices, ", "))
+	}
+}
+
 func GetValuelessUnmarshaller(t reflect.Type,
 	c CustomValuelessUnmarshallers) ValuelessUnmarshaller {
 	u, found := c[t]
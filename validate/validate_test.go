@@ -1,10 +1,12 @@
 package validate
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"mtoohey.com/gah"
+	"mtoohey.com/gah/unmarshal"
 )
 
 func TestRecursiveValidation(t *testing.T) {
@@ -69,6 +71,35 @@ func TestValidateNoFailingParams(t *testing.T) {
 		},
 	}
 	assert.ErrorIs(t, Validate(cmd, true), &ErrFailingParam{})
+	cmd = gah.Cmd{
+		Function: func(f struct {
+			Test string `optionalVal:"auto" choices:"always,never"`
+		}, _ struct{}) {
+		},
+	}
+	assert.ErrorIs(t, Validate(cmd, true), &ErrFailingParam{})
+	cmd = gah.Cmd{
+		Function: func(f struct {
+			Test string `optionalVal:"auto" takesVal:"false"`
+		}, _ struct{}) {
+		},
+	}
+	assert.ErrorIs(t, Validate(cmd, true), &ErrFailingParam{})
+	cmd = gah.Cmd{
+		Function: func(f struct {
+			Test string `fromFile:"" maxSize:"1MiB"`
+		}, _ struct{}) {
+		},
+	}
+	assert.ErrorIs(t, Validate(cmd, true), &ErrFailingParam{})
+	cmd = gah.Cmd{
+		Function: func(_ struct{},
+			a struct {
+				Test string `stdin:"" trim:"lines"`
+			}) {
+		},
+	}
+	assert.ErrorIs(t, Validate(cmd, true), &ErrFailingParam{})
 	cmd = gah.Cmd{
 		Function: func(_ struct{},
 			a struct {
@@ -160,6 +191,13 @@ func TestValidateNoEmptyLongFlags(t *testing.T) {
 		},
 	}
 	assert.ErrorIs(t, Validate(cmd, true), &ErrEmptyLongFlag{})
+	cmd = gah.Cmd{
+		Function: func(f struct {
+			Test1 bool `aliases:"test,"`
+		}, _ struct{}) {
+		},
+	}
+	assert.ErrorIs(t, Validate(cmd, true), &ErrEmptyLongFlag{})
 }
 
 func TestValidateNoMultiRuneShortFlags(t *testing.T) {
@@ -170,6 +208,20 @@ func TestValidateNoMultiRuneShortFlags(t *testing.T) {
 		},
 	}
 	assert.ErrorIs(t, Validate(cmd, true), &ErrMultiRuneShortFlag{})
+	cmd = gah.Cmd{
+		Function: func(f struct {
+			Test1 bool `short:"t,tt"`
+		}, _ struct{}) {
+		},
+	}
+	assert.ErrorIs(t, Validate(cmd, true), &ErrMultiRuneShortFlag{})
+	cmd = gah.Cmd{
+		Function: func(f struct {
+			Test1 bool `short:"t,T"`
+		}, _ struct{}) {
+		},
+	}
+	assert.NoError(t, Validate(cmd, true))
 }
 
 func TestValidateNoConflictingShortFlags(t *testing.T) {
@@ -181,10 +233,61 @@ func TestValidateNoConflictingShortFlags(t *testing.T) {
 		},
 	}
 	assert.ErrorIs(t, Validate(cmd, true), &ErrConflictingShortFlags{})
+	cmd = gah.Cmd{
+		Function: func(f struct {
+			Test1 bool   `short:"t,T"`
+			Test2 string `short:"s,T"`
+		}, _ struct{}) {
+		},
+	}
+	assert.ErrorIs(t, Validate(cmd, true), &ErrConflictingShortFlags{})
+	cmd = gah.Cmd{
+		Function: func(f struct {
+			Verbose bool
+			Version bool
+		}, _ struct{}) {
+		},
+	}
+	assert.ErrorIs(t, Validate(cmd, true), &ErrConflictingShortFlags{})
+	cmd = gah.Cmd{
+		Function: func(f struct {
+			Path  string `short:"o"`
+			Owner string
+		}, _ struct{}) {
+		},
+	}
+	assert.ErrorIs(t, Validate(cmd, true), &ErrConflictingShortFlags{})
+	cmd = gah.Cmd{
+		Function: func(f struct {
+			Output string
+			Owner  string `short:"-"`
+		}, _ struct{}) {
+		},
+	}
+	assert.NoError(t, Validate(cmd, true))
+	cmd = gah.Cmd{
+		ShortFlags: gah.ShortFlagsExplicit,
+		Function: func(f struct {
+			Verbose bool
+			Version bool `short:"V"`
+		}, _ struct{}) {
+		},
+	}
+	assert.NoError(t, Validate(cmd, true))
+	cmd = gah.Cmd{
+		ShortFlags: gah.ShortFlagsNone,
+		Function: func(f struct {
+			Verbose bool `short:"v"`
+			Version bool `short:"v"`
+		}, _ struct{}) {
+		},
+	}
+	assert.NoError(t, Validate(cmd, true))
 }
 
 func TestValidateNoConflictingLongFlags(t *testing.T) {
 	cmd := gah.Cmd{
+		ShortFlags: gah.ShortFlagsExplicit,
 		Function: func(f struct {
 			Test1 bool   `long:"test"`
 			Test2 string `long:"test"`
@@ -193,6 +296,7 @@ func TestValidateNoConflictingLongFlags(t *testing.T) {
 	}
 	assert.ErrorIs(t, Validate(cmd, true), &ErrConflictingLongFlags{})
 	cmd = gah.Cmd{
+		ShortFlags: gah.ShortFlagsExplicit,
 		Function: func(f struct {
 			Test1 bool `long:"test-2"`
 			Test2 string
@@ -200,6 +304,41 @@ func TestValidateNoConflictingLongFlags(t *testing.T) {
 		},
 	}
 	assert.ErrorIs(t, Validate(cmd, true), &ErrConflictingLongFlags{})
+	cmd = gah.Cmd{
+		ShortFlags: gah.ShortFlagsExplicit,
+		Function: func(f struct {
+			Output string `aliases:"out"`
+			Out    bool
+		}, _ struct{}) {
+		},
+	}
+	assert.ErrorIs(t, Validate(cmd, true), &ErrConflictingLongFlags{})
+	cmd = gah.Cmd{
+		ShortFlags: gah.ShortFlagsExplicit,
+		Function: func(f struct {
+			Output string `long:"output,out"`
+			Other  string `long:"other,out"`
+		}, _ struct{}) {
+		},
+	}
+	assert.ErrorIs(t, Validate(cmd, true), &ErrConflictingLongFlags{})
+	cmd = gah.Cmd{
+		ShortFlags: gah.ShortFlagsExplicit,
+		Function: func(f struct {
+			Output string `aliases:"out,output"`
+		}, _ struct{}) {
+		},
+	}
+	assert.ErrorIs(t, Validate(cmd, true), &ErrConflictingLongFlags{})
+	cmd = gah.Cmd{
+		ShortFlags: gah.ShortFlagsExplicit,
+		Function: func(f struct {
+			Output  string `aliases:"out,o-file"`
+			Verbose bool   `short:"V"`
+		}, _ struct{}) {
+		},
+	}
+	assert.NoError(t, Validate(cmd, true))
 }
 
 func TestValidateNoConflictingSubcommands(t *testing.T) {
@@ -314,6 +453,26 @@ func TestValidateOneOrFewerVariableArguments(t *testing.T) {
 		},
 	}
 	assert.ErrorIs(t, Validate(cmd, true), &ErrMultipleVariableArguments{})
+
+	cmd = gah.Cmd{
+		Function: func(_ struct{}, a struct {
+			Sources []string
+			Dest    string
+			Fixed   []int    `min:"2" max:"2"`
+			Rest    []string `subcommandArgs:""`
+		}) {
+		},
+	}
+	assert.NoError(t, Validate(cmd, true))
+
+	cmd = gah.Cmd{
+		Function: func(_ struct{}, a struct {
+			Numbers []int `lazy:""`
+			Names   []string
+		}) {
+		},
+	}
+	assert.NoError(t, Validate(cmd, true))
 }
 
 func TestValidateNoArgsAndSubcommands(t *testing.T) {
@@ -326,3 +485,170 @@ func TestValidateNoArgsAndSubcommands(t *testing.T) {
 	}
 	assert.ErrorIs(t, Validate(cmd, true), &ErrArgsAndSubcommands{})
 }
+
+func TestValidateFlagGroups(t *testing.T) {
+	type options struct {
+		Host string
+		Port int `short:"p"`
+	}
+
+	cmd := gah.Cmd{
+		Function: func(f struct {
+			Primary options `prefix:"primary-"`
+			Replica options `prefix:"replica-"`
+		}, _ struct{}) {
+		},
+	}
+	assert.ErrorIs(t, Validate(cmd, true), &ErrConflictingShortFlags{})
+
+	cmd = gah.Cmd{
+		Function: func(f struct {
+			Host    string
+			Primary options `prefix:""`
+		}, _ struct{}) {
+		},
+	}
+	assert.ErrorIs(t, Validate(cmd, true), &ErrConflictingLongFlags{})
+
+	cmd = gah.Cmd{
+		Function: func(f struct {
+			Host    string
+			Primary options `prefix:"primary-"`
+		}, _ struct{}) {
+		},
+	}
+	assert.NoError(t, Validate(cmd, true))
+}
+
+type structRoot struct {
+	Good structGood `cmd:"good"`
+	Bad  structBad  `cmd:"bad"`
+}
+
+type structGood struct{}
+
+func (structGood) Run(_ struct{ Verbose bool }, _ struct{}) {}
+
+type structBad struct{}
+
+func (structBad) Run() {}
+
+func TestValidateFromStruct(t *testing.T) {
+	cmd := gah.FromStruct(structRoot{})
+	cmd.Name = "root"
+	assert.ErrorIs(t, Validate(cmd, true), &ErrFunctionTakesNonTwoArgs{})
+
+	cmd.Subcommands = cmd.Subcommands[:1]
+	assert.NoError(t, Validate(cmd, true))
+}
+
+func TestValidateNewCmd(t *testing.T) {
+	type flags struct{ Verbose bool }
+
+	cmd := gah.NewCmd("app", func(flags, struct{}) error { return nil },
+		gah.WithDefaultFlags(flags{Verbose: true}))
+	assert.NoError(t, Validate(cmd, true))
+}
+
+func TestValidateOnEval(t *testing.T) {
+	cmd := gah.Cmd{Function: func(struct{}) {}}
+
+	err := cmd.Eval([]string{""}, nil)
+	assert.ErrorIs(t, err, &gah.ErrInvalidCommandDefinition{})
+	assert.ErrorIs(t, err, &ErrFunctionTakesNonTwoArgs{})
+}
+
+func TestValidateAll(t *testing.T) {
+	type point struct{ X, Y int }
+
+	cmd := gah.Cmd{
+		Name: "app",
+		Function: func(f struct {
+			Verbose bool
+			Version bool
+			Level   int `minVal:"low" default:"high"`
+		}, _ struct{}) {
+		},
+		Subcommands: []gah.Cmd{
+			{
+				Name:     "run",
+				Function: func(struct{}) {},
+			},
+			{
+				Name: "move",
+				Function: func(f struct {
+					To point `long:""`
+				}, a struct {
+					Files []string `min:"x"`
+				}) {
+				},
+				CustomValueUnmarshallers: unmarshal.CustomValueUnmarshallers{
+					reflect.TypeOf(point{}): func(string, reflect.StructTag) (reflect.Value, error) {
+						return reflect.ValueOf(point{}), nil
+					},
+				},
+			},
+			{Name: "run"},
+		},
+	}
+
+	errs := ValidateAll(cmd)
+	assert.Len(t, errs, 7)
+
+	expected := []struct {
+		path  []string
+		field string
+		err   error
+	}{
+		{[]string{"app"}, "Level", &ErrFailingParam{}},
+		{[]string{"app"}, "Version", &ErrConflictingShortFlags{}},
+		{[]string{"app"}, "Level", &ErrFailingDefault{}},
+		{[]string{"app"}, "", &ErrConflictingSubcommands{}},
+		{[]string{"app", "run"}, "", &ErrFunctionTakesNonTwoArgs{}},
+		{[]string{"app", "move"}, "Files", &ErrFailingParam{}},
+		{[]string{"app", "move"}, "To", &ErrEmptyLongFlag{}},
+	}
+	for i, e := range expected {
+		if i >= len(errs) {
+			break
+		}
+
+		assert.ErrorIs(t, errs[i], &ErrInCommand{})
+		assert.ErrorIs(t, errs[i], e.err)
+		assert.Equal(t, e.path, errs[i].(*ErrInCommand).Path())
+		assert.Equal(t, e.field, errs[i].(*ErrInCommand).Field())
+	}
+	assert.Contains(t, errs[4].Error(), "app run: ")
+
+	assert.ErrorIs(t, Validate(cmd, true), &ErrFailingParam{})
+	assert.Empty(t, ValidateAll(gah.Cmd{Function: func(struct{}, struct{}) {}}))
+}
+
+func TestValidateBuiltinShortFlags(t *testing.T) {
+	var verbose bool
+
+	cmd := gah.Cmd{
+		Version: "1.0",
+		Function: func(f struct {
+			Verbose bool
+			Host    string
+		}, _ struct{}) {
+			verbose = f.Verbose
+		},
+	}
+	assert.NoError(t, Validate(cmd, true))
+
+	// flags take precedence over the builtin flags they shadow
+	assert.NoError(t, cmd.Eval([]string{"", "-v"}, nil))
+	assert.True(t, verbose)
+}
+
+func TestValidateChoices(t *testing.T) {
+	cmd := gah.Cmd{
+		Function: func(f struct {
+			Level int `choices:"1,2,high"`
+		}, _ struct{}) {
+		},
+	}
+	assert.ErrorIs(t, Validate(cmd, true), &ErrFailingParam{})
+}
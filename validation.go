@@ -0,0 +1,188 @@
+package gah
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Validate checks c, and its subcommands if recursive is true, returning the
+// first problem found with its definition.
+//
+// Eval and Parse also validate the whole tree before parsing, unless the root
+// command sets SkipValidation or the program is built with the gah_novalidate
+// build tag. They only report definitions that can't be parsed, and not those
+// that can be parsed but probably not as intended, such as conflicting flag
+// names, which only Validate and ValidateAll report.
+//
+// The results of the checks on each command's Function are cached by its
+// type, but the tree itself is walked on every call.
+func Validate(c Cmd, recursive bool) error {
+	return firstProblem(c, recursive, true)
+}
+
+// ValidateAll checks c and all of its subcommands, returning every problem
+// found with their definitions. Each error is an *ErrInCommand, recording the
+// command and field it was found in.
+func ValidateAll(c Cmd) []error {
+	var errs []error
+	validate(c, []string{c.Name}, true, true, func(path []string, field string, err error) {
+		errs = append(errs, &ErrInCommand{path: path, field: field, error: err})
+	})
+
+	return errs
+}
+
+// validateTree validates c if validation is enabled, wrapping any error in an
+// ErrInvalidCommandDefinition. Only the checks for definitions that can't be
+// parsed are run, see Validate.
+func (c Cmd) validateTree() error {
+	if !validateOnEval || c.SkipValidation {
+		return nil
+	}
+
+	if err := firstProblem(c, true, false); err != nil {
+		return &ErrInvalidCommandDefinition{error: err}
+	}
+
+	return nil
+}
+
+// firstProblem returns the first problem found by validate.
+func firstProblem(c Cmd, recursive, strict bool) error {
+	var first error
+	validate(c, []string{c.Name}, recursive, strict, func(_ []string, _ string, err error) {
+		if first == nil {
+			first = err
+		}
+	})
+
+	return first
+}
+
+// reporter is called by validators with each problem they find, and the name
+// of the field it concerns if there is one.
+type reporter func(field string, err error)
+
+// validate reports the problems with c, and its subcommands if recursive is
+// true, including those found by the strict validators if strict is true.
+func validate(c Cmd, path []string, recursive, strict bool,
+	report func(path []string, field string, err error)) {
+	problems := 0
+	commandReport := func(field string, err error) {
+		problems++
+		report(path, field, err)
+	}
+
+	// the remaining validators assume that the function's signature is valid
+	validSignature := true
+	if c.Function != nil {
+		for _, v := range signatureValidators {
+			if err := v(c); err != nil {
+				commandReport("", err)
+				validSignature = false
+				break
+			}
+		}
+	}
+
+	if validSignature {
+		if c.Function != nil {
+			for _, p := range c.functionProblems() {
+				if strict || !p.strict {
+					commandReport(p.field, p.error)
+				}
+			}
+		}
+
+		for _, v := range universalValidators {
+			runValidator(v, c, strict, commandReport, problems)
+		}
+	}
+
+	if c.Subcommands != nil {
+		for _, v := range subcommandValidators {
+			runValidator(v, c, strict, commandReport, problems)
+		}
+
+		if recursive {
+			for _, subcommand := range c.Subcommands {
+				subcommandPath := append(append([]string{}, path...), subcommand.Name)
+				validate(subcommand, subcommandPath, recursive, strict, report)
+			}
+		}
+	}
+}
+
+// problem is a problem found by a validator, which is strict if the
+// validator is.
+type problem struct {
+	field  string
+	error  error
+	strict bool
+}
+
+// functionKey holds everything about a command that the function validators
+// depend on, other than its custom unmarshallers.
+type functionKey struct {
+	function     reflect.Type
+	defaultFlags reflect.Type
+	shortFlags   ShortFlagPolicy
+}
+
+// functionProblemsCache holds the problems found by the function validators
+// for each functionKey, so that commands are only checked once, however many
+// times they are evaluated. It is bounded by the number of function types in
+// the program.
+var functionProblemsCache sync.Map
+
+// functionProblems returns the problems found by the function validators for
+// c, which must have a function with a valid signature.
+func (c Cmd) functionProblems() []problem {
+	// the unmarshaller maps aren't comparable, so commands with them aren't
+	// cached
+	cacheable := len(c.CustomValueUnmarshallers) == 0 && len(c.CustomValuelessUnmarshallers) == 0
+	key := functionKey{
+		function:     reflect.TypeOf(c.Function),
+		defaultFlags: reflect.TypeOf(c.DefaultFlags),
+		shortFlags:   c.ShortFlags,
+	}
+
+	if cacheable {
+		if problems, ok := functionProblemsCache.Load(key); ok {
+			return problems.([]problem)
+		}
+	}
+
+	var problems []problem
+	for _, v := range functionValidators {
+		report := func(field string, err error) {
+			problems = append(problems, problem{field: field, error: err, strict: v.strict})
+		}
+		runValidator(v, c, true, report, len(problems))
+	}
+
+	if cacheable {
+		functionProblemsCache.Store(key, problems)
+	}
+
+	return problems
+}
+
+// runValidator runs v, unless it is strict and strict is false, reporting a
+// panic as a problem unless problems have already been reported for c, since
+// validators may be unable to cope with definitions that earlier validators
+// found to be invalid.
+func runValidator(v validator, c Cmd, strict bool, report reporter, problems int) {
+	if v.strict && !strict {
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil && problems == 0 {
+			report("", fmt.Errorf("%v", r))
+		}
+	}()
+
+	v.check(c, report)
+}
@@ -0,0 +1,5 @@
+//go:build gah_novalidate
+
+package gah
+
+const validateOnEval = false
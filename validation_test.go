@@ -0,0 +1,61 @@
+package gah
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidation(t *testing.T) {
+	if !validateOnEval {
+		t.Skip("validation is disabled by the gah_novalidate build tag")
+	}
+
+	cmd := Cmd{
+		Function: func(f struct {
+			Channel chan int
+		}, _ struct{}) {
+		},
+	}
+	err := cmd.Eval([]string{"", "--channel", "x"}, nil)
+	assert.ErrorIs(t, err, &ErrInvalidCommandDefinition{})
+	assert.ErrorIs(t, err, &ErrMissingValueUnmarshaller{})
+	_, err = cmd.Parse([]string{""}, nil)
+	assert.ErrorIs(t, err, &ErrMissingValueUnmarshaller{})
+
+	cmd.SkipValidation = true
+	assert.NoError(t, cmd.Eval([]string{""}, nil))
+
+	// conflicts are resolved as they always have been, by the last flag
+	// winning, so they're only reported by Validate
+	var verbose, values bool
+	cmd = Cmd{
+		Function: func(f struct {
+			Verbose bool
+			Values  bool
+		}, _ struct{}) {
+			verbose, values = f.Verbose, f.Values
+		},
+	}
+	assert.NoError(t, cmd.Eval([]string{"", "-v"}, nil))
+	assert.False(t, verbose)
+	assert.True(t, values)
+	assert.ErrorIs(t, Validate(cmd, true), &ErrConflictingShortFlags{})
+}
+
+func TestValidationCache(t *testing.T) {
+	type flags struct{ Verbose bool }
+
+	cmd := Cmd{Function: func(flags, struct{}) {}}
+	key := functionKey{function: reflect.TypeOf(cmd.Function)}
+	assert.NoError(t, Validate(cmd, true))
+	_, ok := functionProblemsCache.Load(key)
+	assert.True(t, ok)
+
+	cmd.ShortFlags = ShortFlagsNone
+	assert.NoError(t, Validate(cmd, true))
+	_, ok = functionProblemsCache.Load(functionKey{function: key.function,
+		shortFlags: ShortFlagsNone})
+	assert.True(t, ok)
+}
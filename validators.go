@@ -0,0 +1,503 @@
+package gah
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"mtoohey.com/gah/internal/fields"
+	"mtoohey.com/gah/unmarshal"
+)
+
+var signatureValidators = []func(Cmd) error{
+	validateFunctionIsFunction,
+	validateFunctionTakesTwoArgs,
+	validateFunctionTakesStructArgs,
+}
+
+// validator is a check run on a command, which reports each problem it finds.
+// Strict validators report definitions that can be parsed, but probably not
+// as intended, such as flags with the same name, where the last one wins.
+// Unlike the others, they are only run by Validate and ValidateAll, and not
+// before Eval and Parse, so that commands that could be parsed before
+// validation was added still can be.
+type validator struct {
+	check  func(Cmd, reporter)
+	strict bool
+}
+
+var subcommandValidators = []validator{
+	{check: validateNoConflictingSubcommands, strict: true},
+}
+
+var functionValidators = []validator{
+	{check: validateNoFailingParams},
+	{check: validateValueUmarshallers},
+	{check: validateValuelessUmarshallers},
+	{check: validateSubcommandArgsOnCorrectType},
+	{check: validateNoEmptyShortFlags},
+	{check: validateNoEmptyLongFlags},
+	{check: validateNoMultiRuneShortFlags},
+	{check: validateNoConflictingShortFlags, strict: true},
+	{check: validateNoConflictingLongFlags, strict: true},
+	{check: validateNoFailingDefaults},
+	{check: validateDynamicDefaultFlagsType},
+	{check: validateOneOrFewerVariableArguments, strict: true},
+}
+
+var universalValidators = []validator{
+	{check: validateNoArgsAndSubcommands, strict: true},
+}
+
+func validateFunctionIsFunction(c Cmd) error {
+	functionKind := reflect.TypeOf(c.Function).Kind()
+
+	if functionKind != reflect.Func {
+		return &ErrFunctionIsNotFunction{functionKind: functionKind}
+	}
+
+	return nil
+}
+
+func validateFunctionTakesTwoArgs(c Cmd) error {
+	numIn := reflect.TypeOf(c.Function).NumIn()
+
+	if numIn != 2 {
+		return &ErrFunctionTakesNonTwoArgs{numFunctionArgs: numIn}
+	}
+
+	return nil
+}
+
+func validateFunctionTakesStructArgs(c Cmd) error {
+	inZeroKind := reflect.TypeOf(c.Function).In(0).Kind()
+
+	if inZeroKind != reflect.Struct {
+		return &ErrFunctionTakesNonStructArg{argumentIndex: 0, argumentKind: inZeroKind}
+	}
+
+	inOneKind := reflect.TypeOf(c.Function).In(1).Kind()
+
+	if inOneKind != reflect.Struct {
+		return &ErrFunctionTakesNonStructArg{argumentIndex: 1, argumentKind: inOneKind}
+	}
+
+	return nil
+}
+
+func validateNoFailingParams(c Cmd, report reporter) {
+	functionType := reflect.TypeOf(c.Function)
+
+	for _, field := range FlagFields(functionType.In(0)) {
+		takesVal, found := field.Tag.Lookup("takesVal")
+		if found {
+			_, err := strconv.ParseBool(takesVal)
+			if err != nil {
+				report(field.Name, &ErrFailingParam{paramName: "takesVal", paramString: takesVal,
+					flagName: field.Name, error: err})
+				continue
+			}
+		}
+
+		u := valueUnmarshaller(field.Type, c.CustomValueUnmarshallers)
+
+		optionalVal, found := unmarshal.OptionalValue(field.Tag)
+		if found {
+			if !unmarshal.TakesValue(field) {
+				report(field.Name, &ErrFailingParam{paramName: "optionalVal", paramString: optionalVal,
+					flagName: field.Name, error: errors.New("flag does not take a value")})
+			} else if u != nil {
+				_, err := u(optionalVal, field.Tag)
+				if err != nil {
+					report(field.Name, &ErrFailingParam{paramName: "optionalVal", paramString: optionalVal,
+						flagName: field.Name, error: err})
+				}
+			}
+		}
+
+		if err := validateSourceParams(field); err != nil {
+			report(field.Name, err)
+		}
+
+		validateValueParams(field, u, report)
+	}
+
+	for _, field := range reflect.VisibleFields(functionType.In(1)) {
+		for _, paramName := range []string{"min", "max"} {
+			param, found := field.Tag.Lookup(paramName)
+			if found {
+				_, err := strconv.Atoi(param)
+				if err != nil {
+					report(field.Name, &ErrFailingParam{paramName: paramName, paramString: param,
+						flagName: field.Name, error: err})
+				}
+			}
+		}
+
+		if err := validateSourceParams(field); err != nil {
+			report(field.Name, err)
+		}
+
+		validateValueParams(field, valueUnmarshaller(field.Type, c.CustomValueUnmarshallers), report)
+	}
+}
+
+// validateValueParams checks that field's minVal, maxVal and choices tags can
+// be unmarshalled by u, if it isn't nil.
+func validateValueParams(field reflect.StructField, u unmarshal.ValueUnmarshaller, report reporter) {
+	if u == nil {
+		return
+	}
+
+	for _, paramName := range []string{"minVal", "maxVal"} {
+		param, found := field.Tag.Lookup(paramName)
+		if found {
+			_, err := u(param, "")
+			if err != nil {
+				report(field.Name, &ErrFailingParam{paramName: paramName, paramString: param,
+					flagName: field.Name, error: err})
+			}
+		}
+	}
+
+	choices, _ := unmarshal.Choices(field.Tag)
+	for _, choice := range choices {
+		_, err := u(choice, "")
+		if err != nil {
+			report(field.Name, &ErrFailingParam{paramName: "choices", paramString: choice,
+				flagName: field.Name, error: err})
+		}
+	}
+}
+
+// valueUnmarshaller returns the value unmarshaller for t, or nil if there
+// isn't one.
+func valueUnmarshaller(t reflect.Type, c unmarshal.CustomValueUnmarshallers) (u unmarshal.ValueUnmarshaller) {
+	defer func() {
+		if recover() != nil {
+			u = nil
+		}
+	}()
+
+	return unmarshal.GetValueUnmarshaller(t, c)
+}
+
+// hasValuelessUnmarshaller reports whether there is a valueless unmarshaller
+// for t.
+func hasValuelessUnmarshaller(t reflect.Type, c unmarshal.CustomValuelessUnmarshallers) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	unmarshal.GetValuelessUnmarshaller(t, c)
+	return true
+}
+
+func validateSourceParams(field reflect.StructField) error {
+	fromFile, found := field.Tag.Lookup("fromFile")
+	if found && fromFile != "" && fromFile != "always" {
+		return &ErrFailingParam{paramName: "fromFile", paramString: fromFile,
+			flagName: field.Name, error: errors.New(`should be "" or "always"`)}
+	}
+
+	maxSize, found := field.Tag.Lookup("maxSize")
+	if found {
+		_, err := strconv.ParseInt(maxSize, 10, 64)
+		if err != nil {
+			return &ErrFailingParam{paramName: "maxSize", paramString: maxSize,
+				flagName: field.Name, error: err}
+		}
+	}
+
+	trim, found := field.Tag.Lookup("trim")
+	if found && trim != "newline" && trim != "space" {
+		return &ErrFailingParam{paramName: "trim", paramString: trim,
+			flagName: field.Name, error: errors.New(`should be "newline" or "space"`)}
+	}
+
+	return nil
+}
+
+func validateValueUmarshallers(c Cmd, report reporter) {
+	functionType := reflect.TypeOf(c.Function)
+
+	for _, field := range FlagFields(functionType.In(0)) {
+		if unmarshal.TakesValue(field) &&
+			valueUnmarshaller(field.Type, c.CustomValueUnmarshallers) == nil {
+			report(field.Name, &ErrMissingValueUnmarshaller{valueType: field.Type})
+		}
+	}
+
+	for _, field := range reflect.VisibleFields(functionType.In(1)) {
+		if unmarshal.TakesValue(field) {
+			valueType := field.Type
+			switch field.Type.Kind() {
+			case reflect.Slice:
+				valueType = field.Type.Elem()
+			case reflect.Array:
+				valueType = field.Type.Elem()
+			}
+
+			if valueUnmarshaller(valueType, c.CustomValueUnmarshallers) == nil {
+				report(field.Name, &ErrMissingValueUnmarshaller{valueType: valueType})
+			}
+		}
+	}
+}
+
+func validateValuelessUmarshallers(c Cmd, report reporter) {
+	for _, field := range FlagFields(reflect.TypeOf(c.Function).In(0)) {
+		if !unmarshal.TakesValue(field) &&
+			!hasValuelessUnmarshaller(field.Type, c.CustomValuelessUnmarshallers) {
+			report(field.Name, &ErrMissingValuelessUnmarshaller{valueType: field.Type})
+		}
+	}
+}
+
+func validateSubcommandArgsOnCorrectType(c Cmd, report reporter) {
+	for _, field := range reflect.VisibleFields(reflect.TypeOf(c.Function).In(1)) {
+		_, found := field.Tag.Lookup("subcommandArgs")
+		if found && field.Type != reflect.TypeOf([]string{}) {
+			report(field.Name, &ErrSubcommandArgsOnIncorrectType{})
+		}
+	}
+}
+
+func validateNoEmptyShortFlags(c Cmd, report reporter) {
+	for _, field := range FlagFields(reflect.TypeOf(c.Function).In(0)) {
+		short, found := field.Tag.Lookup("short")
+		if found {
+			for _, s := range strings.Split(short, ",") {
+				if utf8.RuneCountInString(s) == 0 {
+					report(field.Name, &ErrEmptyShortFlag{flagName: field.Name})
+					break
+				}
+			}
+		}
+	}
+}
+
+func validateNoEmptyLongFlags(c Cmd, report reporter) {
+	for _, field := range FlagFields(reflect.TypeOf(c.Function).In(0)) {
+	keys:
+		for _, key := range []string{"long", "aliases"} {
+			long, found := field.Tag.Lookup(key)
+			if found {
+				for _, l := range strings.Split(long, ",") {
+					if utf8.RuneCountInString(l) == 0 {
+						report(field.Name, &ErrEmptyLongFlag{flagName: field.Name})
+						break keys
+					}
+				}
+			}
+		}
+	}
+}
+
+func validateNoMultiRuneShortFlags(c Cmd, report reporter) {
+	for _, field := range FlagFields(reflect.TypeOf(c.Function).In(0)) {
+		short, found := field.Tag.Lookup("short")
+		if found && short != "-" {
+			for _, s := range strings.Split(short, ",") {
+				if utf8.RuneCountInString(s) > 1 {
+					report(field.Name, &ErrMultiRuneShortFlag{flagName: field.Name, shortFlag: s})
+				}
+			}
+		}
+	}
+}
+
+func validateNoConflictingShortFlags(c Cmd, report reporter) {
+	// flags with the same short names as the builtin flags aren't reported,
+	// since they take precedence over them
+	var shortSoFar [][2]string
+
+	for _, field := range FlagFields(reflect.TypeOf(c.Function).In(0)) {
+		for _, s := range shortFlags(c.ShortFlags, field) {
+			for _, otherShort := range shortSoFar {
+				if s == otherShort[0] {
+					report(field.Name, &ErrConflictingShortFlags{flagNames: []string{
+						otherShort[1], field.Name}})
+				}
+			}
+
+			shortSoFar = append(shortSoFar, [2]string{s, field.Name})
+		}
+	}
+}
+
+// shortFlags returns the short flags for field, including those derived from
+// its name, as they are determined during evaluation.
+func shortFlags(policy ShortFlagPolicy, field reflect.StructField) []string {
+	if policy == ShortFlagsNone {
+		return nil
+	}
+
+	short, found := field.Tag.Lookup("short")
+	if found {
+		if short == "-" {
+			return nil
+		}
+
+		return strings.Split(short, ",")
+	}
+
+	if policy == ShortFlagsExplicit {
+		return nil
+	}
+
+	return []string{string(unicode.ToLower([]rune(field.Name)[0]))}
+}
+
+func validateNoConflictingLongFlags(c Cmd, report reporter) {
+	var longSoFar [][2]string
+
+	for _, field := range FlagFields(reflect.TypeOf(c.Function).In(0)) {
+		long, found := field.Tag.Lookup("long")
+		if !found {
+			long = fields.PascalToKebab(field.Name)
+		}
+
+		names := strings.Split(long, ",")
+		aliases, found := field.Tag.Lookup("aliases")
+		if found {
+			names = append(names, strings.Split(aliases, ",")...)
+		}
+
+		for _, name := range names {
+			for _, otherLong := range longSoFar {
+				if name == otherLong[0] {
+					report(field.Name, &ErrConflictingLongFlags{flagNames: []string{
+						otherLong[1], field.Name}})
+				}
+			}
+
+			longSoFar = append(longSoFar, [2]string{name, field.Name})
+		}
+	}
+}
+
+func validateNoConflictingSubcommands(c Cmd, report reporter) {
+	var namesSoFar [][2]string
+
+	for _, subcommand := range c.Subcommands {
+		for _, otherName := range namesSoFar {
+			if subcommand.Name == otherName[0] {
+				report("", &ErrConflictingSubcommands{subcommandNames: []string{
+					subcommand.Name, otherName[1]}, aliasOrName: subcommand.Name})
+			}
+		}
+
+		namesSoFar = append(namesSoFar, [2]string{subcommand.Name, subcommand.Name})
+
+		for _, alias := range subcommand.Aliases {
+			for _, otherName := range namesSoFar {
+				if alias == otherName[0] {
+					report("", &ErrConflictingSubcommands{subcommandNames: []string{
+						alias, otherName[1]}, aliasOrName: alias})
+				}
+			}
+
+			namesSoFar = append(namesSoFar, [2]string{alias, subcommand.Name})
+		}
+	}
+}
+
+func validateNoFailingDefaults(c Cmd, report reporter) {
+	for _, field := range FlagFields(reflect.TypeOf(c.Function).In(0)) {
+		defaultStr, found := field.Tag.Lookup("default")
+		if !found {
+			continue
+		}
+
+		u := valueUnmarshaller(field.Type, c.CustomValueUnmarshallers)
+		if u == nil {
+			continue
+		}
+
+		_, err := u(defaultStr, field.Tag)
+		if err != nil {
+			report(field.Name, &ErrFailingDefault{defaultString: defaultStr,
+				flagName: field.Name, error: err})
+		}
+	}
+}
+
+func validateDynamicDefaultFlagsType(c Cmd, report reporter) {
+	if c.DefaultFlags == nil {
+		return
+	}
+
+	flagType := reflect.TypeOf(c.Function).In(0)
+	dynamicDefaultFlagsType := reflect.TypeOf(c.DefaultFlags)
+
+	if flagType != dynamicDefaultFlagsType {
+		report("", &ErrMismatchedDynamicDefaultFlags{
+			actual:   dynamicDefaultFlagsType,
+			expected: flagType,
+		})
+	}
+}
+
+// validateOneOrFewerVariableArguments ensures that which values belong to
+// which argument is unambiguous, by requiring every variable argument except
+// the last to declare whether it is greedy or lazy.
+func validateOneOrFewerVariableArguments(c Cmd, report reporter) {
+	var variableSoFar []string
+	undeclared := false
+
+	for _, field := range reflect.VisibleFields(reflect.TypeOf(c.Function).In(1)) {
+		if _, found := field.Tag.Lookup("subcommandArgs"); found ||
+			field.Type.Kind() != reflect.Slice || !unmarshal.ElementWise(field) {
+			continue
+		}
+
+		// unparseable counts are reported by validateNoFailingParams
+		min := 0
+		minStr, found := field.Tag.Lookup("min")
+		if found {
+			var err error
+			min, err = strconv.Atoi(minStr)
+			if err != nil {
+				continue
+			}
+		}
+
+		max := math.MaxInt
+		maxStr, found := field.Tag.Lookup("max")
+		if found {
+			var err error
+			max, err = strconv.Atoi(maxStr)
+			if err != nil {
+				continue
+			}
+		}
+
+		if min == max {
+			continue
+		}
+
+		variableSoFar = append(variableSoFar, field.Name)
+		if undeclared {
+			report(field.Name, &ErrMultipleVariableArguments{
+				argumentNames: append([]string{}, variableSoFar...)})
+		}
+
+		_, greedy := field.Tag.Lookup("greedy")
+		_, lazy := field.Tag.Lookup("lazy")
+		undeclared = !greedy && !lazy
+	}
+}
+
+func validateNoArgsAndSubcommands(c Cmd, report reporter) {
+	if c.Function != nil && len(reflect.VisibleFields(reflect.TypeOf(c.Function).In(1))) != 0 &&
+		c.Subcommands != nil {
+		report("", &ErrArgsAndSubcommands{})
+	}
+}